@@ -2,16 +2,20 @@ package CaddyHeaderVerification
 
 import (
 	"fmt"
+	"maps"
+	"math"
 	"net/http"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
 	"github.com/caddyserver/caddy/v2"
-	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
 	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
 )
@@ -26,7 +30,86 @@ func init() {
 
 // HeaderChecker checks various UA-related headers and compares their versions.
 type HeaderChecker struct {
-	logger *zap.Logger
+	// ProfileFile is a JSON file with the browser profiles. Default: the
+	// profiles embedded in the module.
+	ProfileFile string `json:"profile_file,omitempty"`
+
+	// Checks holds per-check settings keyed by check ID, e.g. "device_memory".
+	Checks map[string]*CheckConfig `json:"checks,omitempty"`
+
+	// SuspiciousThreshold is the score from which a request is classed
+	// "suspicious". Default: 30.
+	SuspiciousThreshold *int `json:"suspicious_threshold,omitempty"`
+
+	// BotThreshold is the score from which a request is classed "bot". Default: 60.
+	BotThreshold *int `json:"bot_threshold,omitempty"`
+
+	// ReportOnly logs and counts all findings but leaves them out of the verdict,
+	// so every request is classed human.
+	ReportOnly bool `json:"report_only,omitempty"`
+
+	// Actions maps a verdict class (human, suspicious, bot) to what is done
+	// with the request. Default: tag.
+	Actions map[string]*Action `json:"actions,omitempty"`
+
+	// HeaderCounts overrides the accepted number of request headers per browser.
+	HeaderCounts map[string]useragent.HeaderRange `json:"header_counts,omitempty"`
+
+	// VersionFloors overrides the lowest accepted major version per browser.
+	VersionFloors useragent.VersionFloors `json:"version_floors,omitempty"`
+
+	// ReleaseFile is a JSON file with the release dates of each browser.
+	// Default: the release data embedded in the module.
+	ReleaseFile string `json:"release_file,omitempty"`
+
+	// ReleaseFloor moves the version floors along with the release data.
+	// Default: only VersionFloors apply.
+	ReleaseFloor *ReleaseFloor `json:"release_floor,omitempty"`
+
+	// FutureReleases is the number of major versions a browser may be ahead
+	// of its latest release, for beta, dev and nightly channels. Default: 3.
+	FutureReleases *int `json:"future_releases,omitempty"`
+
+	// AcceptHeaders overrides the expected Accept values, e.g. "chrome_image".
+	AcceptHeaders map[string]string `json:"accept_headers,omitempty"`
+
+	// DeviceMemory lists the accepted Sec-CH-Device-Memory values. Default: 8.
+	DeviceMemory []string `json:"device_memory,omitempty"`
+
+	// ResponseHeader is the name of the verdict response header. Default: SecureHeader.
+	ResponseHeader string `json:"response_header,omitempty"`
+
+	// DisableResponseHeader stops the verdict response header from being set.
+	DisableResponseHeader bool `json:"disable_response_header,omitempty"`
+
+	// TorPolicy decides what happens to Tor Browser and Mullvad Browser:
+	// allow, challenge (at least suspicious) or block (bot). Default: allow.
+	// Their headers match those of an English Firefox ESR on Windows, so the
+	// policy applies to those users too.
+	TorPolicy string `json:"tor_policy,omitempty"`
+
+	// TorBlockESR confirms that tor_policy block may block English Firefox
+	// ESR users on Windows, whose headers are those of Tor Browser. Without
+	// it tor_policy block is rejected.
+	TorBlockESR bool `json:"tor_block_esr,omitempty"`
+
+	// InAppPolicy decides what happens to in-app browsers and Android WebView:
+	// allow, challenge (at least suspicious) or block (bot). Default: allow.
+	InAppPolicy string `json:"in_app_policy,omitempty"`
+
+	// InAppPackages lists the Android application IDs accepted in the
+	// X-Requested-With header of Android WebView. Default: every app.
+	InAppPackages []string `json:"in_app_packages,omitempty"`
+
+	// Upstream adds a signed verdict header to the request passed to the next handler.
+	Upstream *UpstreamHeader `json:"upstream_header,omitempty"`
+
+	logger   *zap.Logger
+	profiles *useragent.Profiles
+	releases *useragent.Releases
+
+	// now returns the current time. Default: time.Now.
+	now func() time.Time
 }
 
 // CaddyModule returns the Caddy module information.
@@ -36,12 +119,6 @@ func (HeaderChecker) CaddyModule() caddy.ModuleInfo {
 		New: func() caddy.Module { return new(HeaderChecker) },
 	}
 }
-func (h *HeaderChecker) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
-	// No arguments needed, just consume the directive
-	for d.Next() {
-	}
-	return nil
-}
 func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
 	var hc HeaderChecker
 	err := hc.UnmarshalCaddyfile(h.Dispenser)
@@ -52,45 +129,32 @@ func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error)
 
 func (h *HeaderChecker) Provision(ctx caddy.Context) error {
 	h.logger = ctx.Logger(h) // Module-specific logger
-	return nil
-}
-
-var (
-	// 3-digit main version (e.g. 144 from 144.0.0.0)
-	reThreeDigits = regexp.MustCompile(`\b([0-9]{3})\b`)
-
-	// Chrome/144.0.0.0 → 144
-	reChromeFromUA = regexp.MustCompile(`Chrome/([0-9]{3})`)
-
-	// "Google Chrome";v="144" or "Microsoft Edge";v="144"
-	reBrandVersion = regexp.MustCompile(`(?:Google Chrome|Microsoft Edge)"?;v="([0-9]{3})`)
-)
-
-// helper: extract first 3-digit group
-func firstThreeDigits(s string) string {
-	m := reThreeDigits.FindStringSubmatch(s)
-	if len(m) >= 2 {
-		return m[1]
+	if h.ProfileFile != "" {
+		profiles, err := useragent.LoadProfiles(h.ProfileFile)
+		if err != nil {
+			return fmt.Errorf("loading profile_file: %v", err)
+		}
+		h.profiles = profiles
+		h.logger.Info("loaded browser profiles",
+			zap.String("file", h.ProfileFile),
+			zap.String("revision", profiles.Revision),
+		)
 	}
-	return ""
-}
-
-// helper: Chrome version from UA
-func chromeFromUA(ua string) string {
-	m := reChromeFromUA.FindStringSubmatch(ua)
-	if len(m) >= 2 {
-		return m[1]
+	if h.ReleaseFile != "" {
+		releases, err := useragent.LoadReleases(h.ReleaseFile)
+		if err != nil {
+			return fmt.Errorf("loading release_file: %v", err)
+		}
+		h.releases = releases
+		h.logger.Info("loaded browser releases",
+			zap.String("file", h.ReleaseFile),
+			zap.String("revision", releases.Revision),
+		)
 	}
-	return ""
-}
-
-// helper: version from Sec-CH headers for Chrome/Edge
-func brandVersion(s string) string {
-	m := reBrandVersion.FindStringSubmatch(s)
-	if len(m) >= 2 {
-		return m[1]
+	if h.Upstream != nil {
+		h.Upstream.provision()
 	}
-	return ""
+	return initMetrics(ctx.GetMetricsRegistry())
 }
 
 func (h HeaderChecker) logRequest(r *http.Request) {
@@ -170,262 +234,634 @@ func isImageRequest(path, accept, contentType string) bool {
 	return false
 }
 
-func (h HeaderChecker) validateSecFetchRequests(r *http.Request) bool {
+var (
+	// Simple “is this Firefox/Chrome at all?” checks
+	reFirefoxUA = regexp.MustCompile(`Firefox/\d+\.\d+`)
+	reChromeUA  = regexp.MustCompile(`Chrome/\d+\.\d+`)
+)
+
+func secFetchString(site, mode, dest string) string {
+	return fmt.Sprintf("site=%s mode=%s dest=%s", site, mode, dest)
+}
+
+// checkSecFetch reports a finding when the Sec-Fetch headers are missing or
+// do not match the kind of resource that is requested.
+func (h HeaderChecker) checkSecFetch(r *http.Request) *Finding {
 	secFetchSite := r.Header.Get("Sec-Fetch-Site")
 	secFetchMode := r.Header.Get("Sec-Fetch-Mode")
 	secFetchDest := r.Header.Get("Sec-Fetch-Dest")
+	observed := secFetchString(secFetchSite, secFetchMode, secFetchDest)
 
 	//If one of these headers are empty then it isn't a browser request
 	if secFetchSite == "" || secFetchMode == "" || secFetchDest == "" {
-		return false
+		// unless it is a browser version that does not send them
+		if _, bp, ok := h.profile().LookupRequest(r.Header); ok && !bp.SendsSecFetch() {
+			return nil
+		}
+		return &Finding{
+			Check:    CheckSecFetch,
+			Reason:   ReasonSecFetchMissing,
+			Severity: SeverityMedium,
+			Observed: observed,
+		}
 	}
 	accept := r.Header.Get("Accept")
 	path := r.URL.Path
 	ct := r.Header.Get("Content-Type")
+
+	var expected string
 	if isImageRequest(path, accept, ct) {
 		//these are the standard expected headers for images hosted on your own site
-		if secFetchSite == "same-origin" && secFetchMode == "no-cors" && secFetchDest == "image" {
-			return true
-		} else {
-			return false
-		}
+		expected = secFetchString("same-origin", "no-cors", "image")
 	} else if isScriptRequest(path, accept, ct) {
-		if secFetchSite == "same-origin" && secFetchMode == "no-cors" && secFetchDest == "script" {
-			return true
-		} else {
-			return false
+		expected = secFetchString("same-origin", "no-cors", "script")
+	} else {
+		expected = secFetchString("none", "navigate", "document")
+	}
+	if observed == expected {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckSecFetch,
+		Reason:   ReasonSecFetchMismatch,
+		Severity: SeverityLow,
+		Expected: expected,
+		Observed: observed,
+	}
+}
+
+// checkAcceptMatch compares the Accept header of r with the expected values
+// for documents or images.
+func checkAcceptMatch(r *http.Request, check, accept string, targetAccept, targetAcceptImage []string) *Finding {
+	if isImageRequest(r.URL.Path, accept, r.Header.Get("Content-Type")) {
+		if acceptMatches(accept, targetAcceptImage) {
+			return nil
 		}
-	} else if secFetchSite == "none" && secFetchMode == "navigate" && secFetchDest == "document" {
+		return &Finding{
+			Check:    check,
+			Reason:   ReasonAcceptImageMismatch,
+			Severity: SeverityMedium,
+			Expected: strings.Join(targetAcceptImage, " | "),
+			Observed: accept,
+		}
+	}
+	if acceptMatches(accept, targetAccept) {
+		return nil
+	}
+	return &Finding{
+		Check:    check,
+		Reason:   ReasonAcceptMismatch,
+		Severity: SeverityMedium,
+		Expected: strings.Join(targetAccept, " | "),
+		Observed: accept,
+	}
+}
+
+// acceptMatches reports whether accept is one of targets, ignoring case (like
+// grep -i). Profiles without an Accept value for the destination accept any.
+func acceptMatches(accept string, targets []string) bool {
+	if len(targets) == 0 {
 		return true
 	}
+	for _, target := range targets {
+		if strings.EqualFold(accept, target) {
+			return true
+		}
+	}
 	return false
 }
 
-func (h HeaderChecker) validateChromeAcceptHeader(r *http.Request) bool {
-	var reChromeRange = regexp.MustCompile(`Chrome/(13[1-9]|1[4-9][0-9])\.0`)
-	const targetAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
-	const targetAcceptBrave = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8"
-	const targetAcceptImage = "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
-	const targetAcceptImageBrave = "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
-
-	secChUaFullVersionList := r.Header.Get("Sec-Ch-Ua-Full-Version-List")
-	secChUa := r.Header.Get("Sec-Ch-Ua")
-	accept := r.Header.Get("Accept")
+func (h HeaderChecker) checkChromeAccept(r *http.Request) *Finding {
 	ua := r.Header.Get("User-Agent")
-	// Version range check via regex only
-	if !reChromeRange.MatchString(ua) {
-		return false
+	if !reChromeUA.MatchString(ua) {
+		return nil
+	}
+	browser := h.chromeFamily(r)
+	bp, ok := h.profileFor(browser, ua)
+	if !ok {
+		return &Finding{
+			Check:    CheckChromeAccept,
+			Reason:   ReasonAcceptVersionUnsupported,
+			Severity: SeverityMedium,
+			Expected: string(browser) + " " + h.profile().VersionRanges(browser),
+			Observed: ua,
+		}
 	}
+	return checkAcceptMatch(r, CheckChromeAccept, r.Header.Get("Accept"),
+		h.acceptValues(browser, bp, useragent.DestinationDocument),
+		h.acceptValues(browser, bp, useragent.DestinationImage))
+}
 
-	path := r.URL.Path
-	ct := r.Header.Get("Content-Type")
+// chromeFamily returns the browser DetectBrowser finds for a Chromium
+// request: an app, Edge, another Chromium browser with a token of its own,
+// Brave or Chrome. A Chrome User-Agent DetectBrowser takes for another
+// browser, e.g. one with a Firefox/ token, is checked as chrome_android on
+// Android and as chrome elsewhere.
+func (h HeaderChecker) chromeFamily(r *http.Request) useragent.BrowserKind {
+	browser := h.profile().DetectBrowser(r.Header)
+	if _, ok := chromiumBrands[browser]; ok {
+		return browser
+	}
+	if useragent.IsAndroid(r.Header.Get("User-Agent")) {
+		return useragent.BrowserChromeAndroid
+	}
+	return useragent.BrowserChrome
+}
 
-	//Check if the request is for an image
-	if isImageRequest(path, accept, ct) {
+// profileFor returns the profile of browser for the version in ua.
+func (h HeaderChecker) profileFor(browser useragent.BrowserKind, ua string) (useragent.BrowserProfile, bool) {
+	version, ok := useragent.BrowserVersion(browser, ua)
+	if !ok {
+		return useragent.BrowserProfile{}, false
+	}
+	return h.profile().Lookup(browser, version)
+}
 
-		if isBraveFromClientHints(secChUa, secChUaFullVersionList) {
-			if !strings.EqualFold(accept, targetAcceptImageBrave) {
-				if h.logger != nil {
-					h.logger.Warn("Brave Accept image header not matching",
-						zap.String("Received acceptheader", accept),
-						zap.String("target acceptheader", targetAcceptImageBrave),
-					)
-				}
-				return false
-			}
-		} else if !strings.EqualFold(accept, targetAcceptImage) {
-			if h.logger != nil {
-				h.logger.Warn("Chrome Accept image header not matching",
-					zap.String("Received acceptheader", accept),
-					zap.String("target acceptheader", targetAcceptImage),
-				)
-			}
-			return false
-		}
-		return true
+func (h HeaderChecker) checkFirefoxAccept(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	if !reFirefoxUA.MatchString(ua) {
+		return nil
 	}
-	if isBraveFromClientHints(secChUa, secChUaFullVersionList) {
-		if !strings.EqualFold(accept, targetAcceptBrave) {
-			if h.logger != nil {
-				h.logger.Warn("Brave Accept header not matching",
-					zap.String("Received acceptheader", accept),
-					zap.String("target acceptheader", targetAcceptBrave),
-				)
-			}
-			return false
-		}
-	} else if !strings.EqualFold(accept, targetAccept) {
-		if h.logger != nil {
-			h.logger.Warn("Chrome Accept header not matching",
-				zap.String("Received acceptheader", accept),
-				zap.String("target acceptheader", targetAccept),
-			)
+	browser := h.profile().FirefoxVariant(r.Header)
+	bp, ok := h.profileFor(browser, ua)
+	if !ok {
+		return &Finding{
+			Check:    CheckFirefoxAccept,
+			Reason:   ReasonAcceptVersionUnsupported,
+			Severity: SeverityMedium,
+			Expected: string(browser) + " " + h.profile().VersionRanges(browser),
+			Observed: ua,
 		}
-		return false
-	}
-	if h.logger != nil {
-		h.logger.Info("Chrome Accept header good")
 	}
-	return true
-
+	return checkAcceptMatch(r, CheckFirefoxAccept, r.Header.Get("Accept"),
+		h.acceptValues(browser, bp, useragent.DestinationDocument),
+		h.acceptValues(browser, bp, useragent.DestinationImage))
 }
 
-func (h HeaderChecker) DeterminUnRealisticHeaderCount(r *http.Request) bool {
-	// count header keys
-	count := len(r.Header)
-	if h.logger != nil {
-		h.logger.Info("header counting",
-			zap.Int("Header count is =", count),
-		)
+// checkTorBrowser reports Tor Browser and Mullvad Browser unless the policy
+// allows them. It cannot tell them apart from an English Firefox ESR on
+// Windows, which it reports as well.
+func (h HeaderChecker) checkTorBrowser(r *http.Request) *Finding {
+	if h.torPolicy() == TorPolicyAllow || !reFirefoxUA.MatchString(r.Header.Get("User-Agent")) {
+		return nil
 	}
-	if count < 7 {
-		return true
+	if h.profile().FirefoxVariant(r.Header) != useragent.BrowserTor {
+		return nil
 	}
-	if count > 25 {
-		return true
+	return &Finding{
+		Check:    CheckTorBrowser,
+		Reason:   ReasonTorBrowser,
+		Severity: SeverityMedium,
+		Expected: "tor_policy " + h.torPolicy(),
+		Observed: r.Header.Get("User-Agent"),
 	}
-	return false
 }
 
-func (h HeaderChecker) validateFireFoxAcceptHeader(r *http.Request) bool {
-	var reFirefoxRange = regexp.MustCompile(`Firefox/(13[2-9]|1[4-9][0-9])\.0`)
-	const targetAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
-	const targetAcceptImage = "image/avif,image/webp,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
-
-	accept := r.Header.Get("Accept")
-	ua := r.Header.Get("User-Agent")
-	// Version range check via regex only
-	if !reFirefoxRange.MatchString(ua) {
-		return false
+// checkInAppBrowser reports in-app browsers and Android WebView unless the
+// policy allows them.
+func (h HeaderChecker) checkInAppBrowser(r *http.Request) *Finding {
+	if h.inAppPolicy() == InAppPolicyAllow {
+		return nil
 	}
+	browser := useragent.AppBrowser(r.Header)
+	if browser == useragent.BrowserUnknown {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckInAppBrowser,
+		Reason:   ReasonInAppBrowser,
+		Severity: SeverityMedium,
+		Expected: "in_app_policy " + h.inAppPolicy(),
+		Observed: string(browser),
+	}
+}
 
-	path := r.URL.Path
-	ct := r.Header.Get("Content-Type")
-
-	//Check if the request is for an image
-	if isImageRequest(path, accept, ct) {
-		// Case-insensitive Accept match (like grep -i)
-		if !strings.EqualFold(accept, targetAcceptImage) {
-			if h.logger != nil {
-				h.logger.Warn("Firefox Accept image header not matching",
-					zap.String("Received acceptheader", accept),
-					zap.String("target acceptheader", targetAcceptImage),
-				)
-			}
-			return false
+// checkAppPackage checks the application ID in X-Requested-With, which only
+// Android WebView sends, against the accepted in-app packages.
+func (h HeaderChecker) checkAppPackage(r *http.Request) *Finding {
+	pkg, ok := useragent.AppPackage(r.Header)
+	if !ok {
+		return nil
+	}
+	if useragent.AppBrowser(r.Header) == useragent.BrowserUnknown {
+		return &Finding{
+			Check:    CheckAppPackage,
+			Reason:   ReasonAppPackageUnexpected,
+			Severity: SeverityMedium,
+			Expected: "application ID from Android WebView",
+			Observed: pkg,
 		}
-		return true
 	}
+	if len(h.InAppPackages) == 0 || slices.Contains(h.InAppPackages, pkg) {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckAppPackage,
+		Reason:   ReasonAppPackageNotAllowed,
+		Severity: SeverityMedium,
+		Expected: "in_app_packages",
+		Observed: pkg,
+	}
+}
 
-	// Case-insensitive Accept match (like grep -i)
-	if !strings.EqualFold(accept, targetAccept) {
-		if h.logger != nil {
-			h.logger.Warn("Firefox Accept header not matching",
-				zap.String("Received acceptheader", accept),
-				zap.String("target acceptheader", targetAccept),
-			)
+// checkSafariAccept compares the Accept header of Safari requests, and of the
+// iPhone and iPad browsers built on WebKit, with the browser profile.
+func (h HeaderChecker) checkSafariAccept(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	browser := useragent.IOSBrowser(ua)
+	if browser == useragent.BrowserUnknown {
+		if !useragent.IsSafari(ua) {
+			return nil
 		}
-		return false
+		browser = useragent.BrowserSafari
 	}
-	if h.logger != nil {
-		h.logger.Info("Firefox Accept header good")
+	bp, ok := h.profileFor(browser, ua)
+	if !ok {
+		return &Finding{
+			Check:    CheckSafariAccept,
+			Reason:   ReasonAcceptVersionUnsupported,
+			Severity: SeverityMedium,
+			Expected: string(browser) + " " + h.profile().VersionRanges(browser),
+			Observed: ua,
+		}
 	}
-	return true
+	return checkAcceptMatch(r, CheckSafariAccept, r.Header.Get("Accept"),
+		h.acceptValues(browser, bp, useragent.DestinationDocument),
+		h.acceptValues(browser, bp, useragent.DestinationImage))
+}
 
+// checkUAGrammar reports a finding when the User-Agent does not follow the
+// grammar in the browser profile.
+func (h HeaderChecker) checkUAGrammar(r *http.Request) *Finding {
+	_, bp, ok := h.profile().LookupRequest(r.Header)
+	ua := r.Header.Get("User-Agent")
+	if !ok || bp.MatchesUserAgent(ua) {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckUAGrammar,
+		Reason:   ReasonUAGrammarMismatch,
+		Severity: SeverityHigh,
+		Expected: bp.UserAgent,
+		Observed: ua,
+	}
 }
 
-func validateAcceptHeader(acceptHeaderValues []string) bool {
-	// Check if the accept header values indicate it is a bot by accepting everything
+// checkAcceptWildcard reports a finding when the only accepted type is */*,
+// which is what HTTP libraries send by default.
+func (h HeaderChecker) checkAcceptWildcard(r *http.Request) *Finding {
+	acceptHeaderValues := r.Header.Values("Accept")
 	if len(acceptHeaderValues) == 1 && acceptHeaderValues[0] == "*/*" {
-		return false
+		return &Finding{
+			Check:    CheckAcceptWildcard,
+			Reason:   ReasonAcceptWildcard,
+			Severity: SeverityHigh,
+			Observed: acceptHeaderValues[0],
+		}
 	}
-	return true
+	return nil
 }
 
-func isBraveFromClientHints(secChUa, secChUaFullVersionList string) bool {
-	// Very simple heuristic: look for "Brave" in either header.
-	// Adjust if you want case-insensitive matching or stricter parsing.
-	ClientHintUserAgent := strings.ToLower(secChUa)
-	ClienthintFullVersionList := strings.ToLower(secChUaFullVersionList)
-	if strings.Contains(ClientHintUserAgent, "brave") && strings.Contains(ClienthintFullVersionList, "brave") {
-		return true
-	}
+// buildRule says how the full version of a brand relates to the Chromium build.
+type buildRule int
 
-	return false
+const (
+	buildAny      buildRule = iota // not compared, e.g. Brave farbles or omits its builds
+	buildChromium                  // the brand repeats the Chromium build
+	buildOwn                       // the brand has build numbers of its own
+)
+
+// chromiumBrand describes the Sec-CH-UA brand of a Chromium browser.
+type chromiumBrand struct {
+	// brands are the accepted brand names, the first one being the default.
+	brands []string
+
+	// token is set when the brand goes with a UA token of its own, e.g. Edg/.
+	token bool
+
+	// ownVersion is set when the browser numbers its releases apart from
+	// Chromium, so its token and brand versions differ from Chrome/.
+	ownVersion bool
+
+	// exclusive is set when the brand lists hold nothing but the brand,
+	// Chromium and one GREASE brand, as with Brave.
+	exclusive bool
+
+	build buildRule
 }
 
-func (h HeaderChecker) crossReferenceClientHintHeaders(r *http.Request) bool {
-	secChUaFullVersion := r.Header.Get("Sec-Ch-Ua-Full-Version")
-	secChUaFullVersionList := r.Header.Get("Sec-Ch-Ua-Full-Version-List")
-	userAgent := r.Header.Get("User-Agent")
-	secChUa := r.Header.Get("Sec-Ch-Ua")
+// chromiumBrands maps each Chromium browser to its brand.
+var chromiumBrands = map[useragent.BrowserKind]chromiumBrand{
+	useragent.BrowserChrome:         {brands: []string{useragent.BrandChrome}, build: buildChromium},
+	useragent.BrowserChromeAndroid:  {brands: []string{useragent.BrandChrome}, build: buildChromium},
+	useragent.BrowserVivaldi:        {brands: []string{useragent.BrandChrome}, build: buildChromium},
+	useragent.BrowserBrave:          {brands: []string{useragent.BrandBrave}, exclusive: true},
+	useragent.BrowserEdge:           {brands: []string{useragent.BrandEdge}, token: true, build: buildOwn},
+	useragent.BrowserEdgeWebView2:   {brands: []string{useragent.BrandEdge}, token: true, build: buildOwn},
+	useragent.BrowserEdgeAndroid:    {brands: []string{useragent.BrandEdge}, token: true, build: buildOwn},
+	useragent.BrowserOpera:          {brands: []string{useragent.BrandOpera, useragent.BrandOperaGX}, token: true, ownVersion: true},
+	useragent.BrowserSamsung:        {brands: []string{useragent.BrandSamsung}, token: true, ownVersion: true},
+	useragent.BrowserYandex:         {brands: []string{useragent.BrandYandex}, token: true, ownVersion: true},
+	useragent.BrowserAndroidWebView: {brands: []string{useragent.BrandAndroidWebView}, token: true, build: buildChromium},
+	useragent.BrowserInApp:          {brands: []string{useragent.BrandAndroidWebView}, build: buildChromium},
+}
 
-	mainVerSecFull := firstThreeDigits(secChUaFullVersion)
-	mainVerSecFullList := brandVersion(secChUaFullVersionList)
-	mainVerUA := chromeFromUA(userAgent)
-	mainVerSecUa := brandVersion(secChUa)
+// brand returns the first of c.brands in list, or the default brand.
+func (c chromiumBrand) brand(list useragent.BrandList) string {
+	for _, b := range c.brands {
+		if list.Has(b) {
+			return b
+		}
+	}
+	return c.brands[0]
+}
 
-	// Brave exception:
-	// If all "main version" values are empty, but Sec-Ch-Ua or
-	// Sec-Ch-Ua-Full-Version-List clearly indicate Brave, we accept it.
-	if mainVerSecFull == "" &&
-		isBraveFromClientHints(secChUa, secChUaFullVersionList) {
+// checkClientHintVersions cross-references the versions in the UA with the
+// versions in the client hint headers. The brand has to fit the UA: the Edg/,
+// EdgA/, OPR/, SamsungBrowser/ and YaBrowser/ tokens go with their own brand
+// and the other way round. Edge reports a build of its own in the full version list,
+// while Chrome repeats the build of its Chromium entry.
+func (h HeaderChecker) checkClientHintVersions(r *http.Request) *Finding {
+	userAgent := r.Header.Get("User-Agent")
+	if !reChromeUA.MatchString(userAgent) {
+		return nil
+	}
+	secChUa, err := useragent.ParseBrandList(r.Header.Get("Sec-Ch-Ua"))
+	if err != nil {
+		return nil // reported by client_hint_syntax
+	}
+	fullVersionList, err := useragent.ParseBrandList(r.Header.Get("Sec-Ch-Ua-Full-Version-List"))
+	if err != nil {
+		return nil
+	}
+	fullVersion, ok := hintString(r, "Sec-Ch-Ua-Full-Version")
+	if !ok {
+		return nil
+	}
 
-		if h.logger != nil {
-			h.logger.Info("Header versions empty but Brave detected, accepting",
-				zap.String("Sec-Ch-Ua", secChUa),
-				zap.String("Sec-Ch-Ua-Full-Version", secChUaFullVersion),
-				zap.String("Sec-Ch-Ua-Full-Version-List", secChUaFullVersionList),
-				zap.String("User-Agent", userAgent),
-			)
+	browser := h.chromeFamily(r)
+	cb := chromiumBrands[browser]
+	brand := cb.brand(secChUa)
+	if cb.token && len(secChUa) > 0 && !secChUa.Has(brand) {
+		return &Finding{
+			Check:    CheckClientHintVersions,
+			Reason:   ReasonClientHintBrandMismatch,
+			Severity: SeverityHigh,
+			Expected: fmt.Sprintf("%q brand for %s", brand, browser),
+			Observed: r.Header.Get("Sec-Ch-Ua"),
+		}
+	}
+	for _, other := range slices.Sorted(maps.Keys(chromiumBrands)) {
+		ob := chromiumBrands[other]
+		if !ob.token || slices.Equal(ob.brands, cb.brands) {
+			continue
+		}
+		if b := ob.brand(secChUa); secChUa.Has(b) {
+			return &Finding{
+				Check:    CheckClientHintVersions,
+				Reason:   ReasonClientHintBrandMismatch,
+				Severity: SeverityHigh,
+				Expected: fmt.Sprintf("no %q brand without the %s token", b, other),
+				Observed: r.Header.Get("Sec-Ch-Ua"),
+			}
 		}
-		return true
 	}
 
-	// Compare versions similar to your shell script logic
-	if mainVerSecFull == "" || mainVerSecFullList == "" || mainVerUA == "" || mainVerSecUa == "" ||
-		mainVerSecFull != mainVerSecFullList ||
-		mainVerSecFull != mainVerUA ||
-		mainVerSecFull != mainVerSecUa {
+	if cb.exclusive {
+		for _, list := range []useragent.BrandList{secChUa, fullVersionList} {
+			if len(list) > 0 && !list.OnlyBrands(brand, useragent.BrandChromium) {
+				return &Finding{
+					Check:    CheckClientHintVersions,
+					Reason:   ReasonClientHintBrandMismatch,
+					Severity: SeverityHigh,
+					Expected: fmt.Sprintf("only %q, %q and one GREASE brand for %s", brand, useragent.BrandChromium, browser),
+					Observed: r.Header.Get("Sec-Ch-Ua") + " | " + r.Header.Get("Sec-Ch-Ua-Full-Version-List"),
+				}
+			}
+		}
+	}
 
-		if h.logger != nil {
-			h.logger.Warn("Header version mismatch",
-				zap.String("Sec-Ch-Ua-Full-Version", secChUaFullVersion),
-				zap.String("Sec-Ch-Ua-Full-Version-main", mainVerSecFull),
-				zap.String("Sec-Ch-Ua-Full-Version-List", secChUaFullVersionList),
-				zap.String("Sec-Ch-Ua-Full-Version-List-main", mainVerSecFullList),
-				zap.String("User-Agent", userAgent),
-				zap.String("User-Agent-Chrome-main", mainVerUA),
-				zap.String("Sec-Ch-Ua", secChUa),
-				zap.String("Sec-Ch-Ua-main", mainVerSecUa),
-			)
+	// Some browsers, such as Brave, do not send Sec-Ch-Ua-Full-Version and
+	// may leave out Sec-Ch-Ua-Full-Version-List. The hints they do send are
+	// still compared.
+	omitted := false
+	if fullVersion == "" {
+		bp, _ := h.profileFor(browser, userAgent)
+		omitted = bp.OptionalHint("Sec-Ch-Ua-Full-Version")
+	}
+
+	// The brand versions follow the browser token (Edg/ for Edge, OPR/ for
+	// Opera) and the Chromium entries follow Chrome/. Browsers without a
+	// numbering of their own share the major version with Chromium.
+	uaMajor := uaMajorVersion(browser, userAgent)
+	chromiumMajor := uaMajorVersion(useragent.BrowserChrome, userAgent)
+	fullVersionMajor, _, _ := strings.Cut(fullVersion, ".")
+	mismatch := uaMajor == "" || chromiumMajor == "" || secChUa.Major(brand) != uaMajor ||
+		(!cb.ownVersion && uaMajor != chromiumMajor)
+	if fullVersionList.Major(brand) != uaMajor && (!omitted || len(fullVersionList) > 0) {
+		mismatch = true
+	}
+	for _, list := range []useragent.BrandList{secChUa, fullVersionList} {
+		if list.Has(useragent.BrandChromium) && list.Major(useragent.BrandChromium) != chromiumMajor {
+			mismatch = true
 		}
+	}
+	// The deprecated Sec-CH-UA-Full-Version of Opera, Samsung Internet and
+	// Yandex Browser may carry either their own or the Chromium version.
+	if !omitted && fullVersionMajor != uaMajor && (!cb.ownVersion || fullVersionMajor != chromiumMajor) {
+		mismatch = true
+	}
+	if mismatch {
+		return &Finding{
+			Check:    CheckClientHintVersions,
+			Reason:   ReasonClientHintVersionMismatch,
+			Severity: SeverityHigh,
+			Expected: fmt.Sprintf("ua=%s chrome=%s", uaMajor, chromiumMajor),
+			Observed: fmt.Sprintf("full_version=%s full_version_list=%s sec_ch_ua=%s",
+				fullVersionMajor, fullVersionList.Major(brand), secChUa.Major(brand)),
+		}
+	}
 
-		return false
-	} else {
-		if h.logger != nil {
-			h.logger.Info("Header versions match",
-				zap.String("mainVersion", mainVerSecFull),
-			)
+	brandFull, _ := fullVersionList.Version(brand)
+	chromiumFull, ok := fullVersionList.Version(useragent.BrandChromium)
+	if cb.build == buildAny || omitted || !ok {
+		return nil
+	}
+	switch {
+	case fullVersion != brandFull:
+		return &Finding{
+			Check:    CheckClientHintVersions,
+			Reason:   ReasonClientHintBuildMismatch,
+			Severity: SeverityHigh,
+			Expected: brand + " " + brandFull,
+			Observed: fullVersion,
 		}
-		return true
+	case cb.build == buildOwn && brandFull == chromiumFull:
+		return &Finding{
+			Check:    CheckClientHintVersions,
+			Reason:   ReasonClientHintBuildMismatch,
+			Severity: SeverityHigh,
+			Expected: string(browser) + " build other than Chromium " + chromiumFull,
+			Observed: brand + " " + brandFull,
+		}
+	case cb.build == buildChromium && brandFull != chromiumFull:
+		return &Finding{
+			Check:    CheckClientHintVersions,
+			Reason:   ReasonClientHintBuildMismatch,
+			Severity: SeverityHigh,
+			Expected: "Chromium " + chromiumFull,
+			Observed: brand + " " + brandFull,
+		}
+	}
+	return nil
+}
+
+// checkGreaseBrand reports brand lists that do not follow the GREASE algorithm
+// of Chromium. The GREASE brand, its version and the order of the GREASE
+// brand, Chromium and the browser brand all derive from the Chromium major
+// version in the UA, so a hand-written or copied header rarely fits.
+func (h HeaderChecker) checkGreaseBrand(r *http.Request) *Finding {
+	major, ok := useragent.BrowserVersion(useragent.BrowserChrome, r.Header.Get("User-Agent"))
+	if !ok {
+		return nil
+	}
+	for _, hint := range []struct {
+		name string
+		full bool
+	}{
+		{"Sec-Ch-Ua", false},
+		{"Sec-Ch-Ua-Full-Version-List", true},
+	} {
+		// missing and malformed lists are left to the other checks
+		list, err := useragent.ParseBrandList(r.Header.Get(hint.name))
+		if err != nil || len(list) == 0 || list.FollowsGrease(major, hint.full) {
+			continue
+		}
+		return &Finding{
+			Check:    CheckGreaseBrand,
+			Reason:   ReasonGreaseBrandMismatch,
+			Severity: SeverityHigh,
+			Expected: list.ExpectedGrease(major, hint.full),
+			Observed: r.Header.Get(hint.name),
+		}
+	}
+	return nil
+}
+
+// uaMajorVersion returns the major version of browser in ua, or "".
+func uaMajorVersion(browser useragent.BrowserKind, ua string) string {
+	version, ok := useragent.BrowserVersion(browser, ua)
+	if !ok {
+		return ""
 	}
+	return strconv.Itoa(version)
 }
 
-// The following function checks if there is a wrong implementation based on the fact that Linux
-// has a hard implementation of X11; Linux x86_64
+// ValidateSecChUaPlatformLinux reports whether the User-Agent of r carries
+// one of the Linux desktop tokens of the profile, such as X11; Linux x86_64
+// or X11; Linux aarch64.
 func (h HeaderChecker) ValidateSecChUaPlatformLinux(r *http.Request) bool {
+	return h.profile().ValidatePlatformToken("Linux", r.Header.Get("User-Agent")) == nil
+}
+
+// checkLinuxPlatform reports a Linux Sec-CH-UA-Platform whose User-Agent has
+// no Linux desktop token. The other platforms are left to platform_token.
+func (h HeaderChecker) checkLinuxPlatform(r *http.Request) *Finding {
+	if !reChromeUA.MatchString(r.Header.Get("User-Agent")) {
+		return nil
+	}
+	if platform, ok := hintString(r, "Sec-Ch-Ua-Platform"); !ok || platform != "Linux" || h.ValidateSecChUaPlatformLinux(r) {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckLinuxPlatform,
+		Reason:   ReasonLinuxPlatformToken,
+		Severity: SeverityMedium,
+		Expected: strings.Join(h.profile().PlatformTokens["Linux"], ","),
+		Observed: r.Header.Get("User-Agent"),
+	}
+}
+
+// checkPlatformToken reports a Sec-CH-UA-Platform that does not fit the
+// platform token of the User-Agent, e.g. "macOS" next to a Windows UA, or a
+// platform value Chromium does not send.
+func (h HeaderChecker) checkPlatformToken(r *http.Request) *Finding {
 	ua := r.Header.Get("User-Agent")
+	if !reChromeUA.MatchString(ua) {
+		return nil
+	}
+	platform, ok := hintString(r, "Sec-Ch-Ua-Platform")
+	if !ok || platform == "" || platform == "Linux" {
+		return nil
+	}
+	err := h.profile().ValidatePlatformToken(platform, ua)
+	if err == nil {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckPlatformToken,
+		Reason:   ReasonPlatformTokenMismatch,
+		Severity: SeverityHigh,
+		Expected: err.Error(),
+		Observed: fmt.Sprintf("platform=%s user_agent=%s", r.Header.Get("Sec-Ch-Ua-Platform"), ua),
+	}
+}
 
-	// Exclude the accepted default value for desktop Linux browsers: "X11; Linux x86_64"
-	if strings.Contains(ua, "X11; Linux x86_64") {
-		return true
+// checkMobileHints reports a finding when the client hints contradict the
+// User-Agent: Sec-CH-UA-Mobile must be ?1 exactly when the UA has the Mobile
+// token, and Sec-CH-UA-Platform and Sec-CH-UA-Model must fit the profile.
+func (h HeaderChecker) checkMobileHints(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	if !reChromeUA.MatchString(ua) {
+		return nil
 	}
 
-	// If we reach this point: Linux platform, but not a typical X11 desktop UA:
-	// classify as bot.
-	return false
+	if mobile := r.Header.Get("Sec-Ch-Ua-Mobile"); mobile != "" {
+		expected := "?0"
+		if useragent.IsMobile(ua) {
+			expected = "?1"
+		}
+		if got, err := useragent.ParseHintBoolean(mobile); err == nil && got != useragent.IsMobile(ua) {
+			return &Finding{
+				Check:    CheckMobileHints,
+				Reason:   ReasonMobileHintMismatch,
+				Severity: SeverityHigh,
+				Expected: expected,
+				Observed: mobile,
+			}
+		}
+	}
 
+	bp, ok := h.profileFor(h.chromeFamily(r), ua)
+	if !ok {
+		return nil
+	}
+	if platforms := bp.ClientHintPlatforms(); len(platforms) > 0 {
+		if platform, ok := hintString(r, "Sec-Ch-Ua-Platform"); ok && platform != "" && !slices.Contains(platforms, platform) {
+			return &Finding{
+				Check:    CheckMobileHints,
+				Reason:   ReasonPlatformHintMismatch,
+				Severity: SeverityHigh,
+				Expected: strings.Join(platforms, ","),
+				Observed: r.Header.Get("Sec-Ch-Ua-Platform"),
+			}
+		}
+	}
+	if model, sent := r.Header["Sec-Ch-Ua-Model"]; sent {
+		value, err := useragent.ParseHintString(model[0])
+		empty := value == ""
+		rule := bp.ModelRule()
+		if err == nil && ((rule == useragent.ModelEmpty && !empty) || (rule == useragent.ModelNonEmpty && empty)) {
+			return &Finding{
+				Check:    CheckMobileHints,
+				Reason:   ReasonModelHintMismatch,
+				Severity: SeverityMedium,
+				Expected: rule,
+				Observed: model[0],
+			}
+		}
+	}
+	return nil
 }
 
 const DevtoolsPath = "/.well-known/appspecific/com.chrome.devtools.json"
@@ -434,227 +870,485 @@ func IsDevtoolsPath(r *http.Request) bool {
 	return r.URL.Path == DevtoolsPath
 }
 
-// memSetNot8 returns true if Sec-CH-Device-Memory is present and not "8".
+func (h HeaderChecker) checkDevtoolsPath(r *http.Request) *Finding {
+	if !IsDevtoolsPath(r) {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckDevtoolsPath,
+		Reason:   ReasonDevtoolsPath,
+		Severity: SeverityLow,
+		Observed: r.URL.Path,
+	}
+}
+
+// CheckSecCHDeviceMemoryequalto8 returns false if Sec-CH-Device-Memory is missing or
+// not one of the values of the default profiles.
 func CheckSecCHDeviceMemoryequalto8(r *http.Request) bool {
+	return HeaderChecker{}.checkDeviceMemory(r) == nil
+}
+
+// checkDeviceMemory reports a finding when a Chrome request has no
+// Sec-CH-Device-Memory or a value that is not accepted.
+func (h HeaderChecker) checkDeviceMemory(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	if !reChromeUA.MatchString(ua) {
+		return nil
+	}
+	bp, _ := h.profileFor(h.chromeFamily(r), ua)
 	val := r.Header.Get("Sec-Ch-Device-Memory")
-	secChUaFullVersionList := r.Header.Get("Sec-Ch-Ua-Full-Version-List")
-	secChUa := r.Header.Get("Sec-Ch-Ua")
+	allowed := h.deviceMemory(bp)
+	if len(allowed) == 0 {
+		return nil
+	}
 	// Equivalent of `header_regexp ... ^.+$` → header must be non-empty
 	if strings.TrimSpace(val) == "" {
-		if isBraveFromClientHints(secChUa, secChUaFullVersionList) {
-			return true
+		if bp.OptionalHint("Sec-Ch-Device-Memory") {
+			return nil
+		}
+		return &Finding{
+			Check:    CheckDeviceMemory,
+			Reason:   ReasonDeviceMemoryMissing,
+			Severity: SeverityMedium,
+			Expected: strings.Join(allowed, ","),
 		}
-		return false
 	}
 
 	// Equivalent of `not { header Sec-CH-Device-Memory 8 }`
-	if val != "8" {
+	for _, v := range allowed {
+		if val == v {
+			return nil
+		}
+	}
+	return &Finding{
+		Check:    CheckDeviceMemory,
+		Reason:   ReasonDeviceMemoryUnexpected,
+		Severity: SeverityLow,
+		Expected: strings.Join(allowed, ","),
+		Observed: val,
+	}
+}
+
+// CheckCorrectAcceptEncodingCheck returns true if Accept-Encoding is the
+// first accept_encoding value of the default profile of the request's
+// browser, the value it sends over HTTPS. Requests of an unknown browser are
+// held to the newest Chrome profile.
+func CheckCorrectAcceptEncodingCheck(r *http.Request) bool {
+	profiles := HeaderChecker{}.profile()
+	_, bp, ok := profiles.LookupRequest(r.Header)
+	if !ok {
+		bp, ok = profiles.Lookup(useragent.BrowserChrome, math.MaxInt)
+	}
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+	if !ok || len(bp.AcceptEncoding) == 0 || acceptEncoding == "" {
 		return false
 	}
+	return acceptEncodingFinding(bp.AcceptEncoding[:1], acceptEncoding) == nil
+}
 
-	return true
+// checkAcceptEncoding reports a finding when Accept-Encoding is not one of
+// the values in the browser profile.
+func (h HeaderChecker) checkAcceptEncoding(r *http.Request) *Finding {
+	_, bp, ok := h.profile().LookupRequest(r.Header)
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+	if !ok || len(bp.AcceptEncoding) == 0 || acceptEncoding == "" {
+		return nil
+	}
+	return acceptEncodingFinding(bp.AcceptEncoding, acceptEncoding)
 }
 
-func CheckCorrectAcceptEncodingCheck(r *http.Request) bool {
-	AcceptEncoding := r.Header.Get("Accept-Encoding")
-	// Perform a check for the default accept-encoding checks.
-	if AcceptEncoding == "gzip, deflate, br, zstd" {
-		return true
+// acceptEncodingFinding reports a finding when acceptEncoding is not one of
+// allowed.
+func acceptEncodingFinding(allowed []string, acceptEncoding string) *Finding {
+	for _, v := range allowed {
+		if strings.EqualFold(acceptEncoding, v) {
+			return nil
+		}
+	}
+	return &Finding{
+		Check:    CheckAcceptEncoding,
+		Reason:   ReasonAcceptEncodingMismatch,
+		Severity: SeverityLow,
+		Expected: strings.Join(allowed, " | "),
+		Observed: acceptEncoding,
 	}
+}
 
-	return false
+// checkRequiredHeaders reports a finding when a header required by the
+// browser profile is missing, when a forbidden header is present, when a
+// header has another value than the profile allows, or when a header is
+// neither required nor optional in a profile that lists optional headers.
+func (h HeaderChecker) checkRequiredHeaders(r *http.Request) *Finding {
+	browser, bp, ok := h.profile().LookupRequest(r.Header)
+	if !ok {
+		return nil
+	}
+	var missing []string
+	for _, name := range bp.Required(r.ProtoMajor) {
+		if r.Header.Get(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return &Finding{
+			Check:    CheckRequiredHeaders,
+			Reason:   ReasonRequiredHeaderMissing,
+			Severity: SeverityMedium,
+			Expected: strings.Join(missing, ","),
+			Observed: string(browser),
+		}
+	}
+	if name, found := bp.ForbiddenHeader(r.Header); found {
+		return &Finding{
+			Check:    CheckRequiredHeaders,
+			Reason:   ReasonForbiddenHeader,
+			Severity: SeverityHigh,
+			Expected: strings.Join(bp.ForbiddenHeaders, ","),
+			Observed: name,
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(bp.HeaderValues)) {
+		if v := r.Header.Get(name); v != "" && v != bp.HeaderValues[name] {
+			return &Finding{
+				Check:    CheckRequiredHeaders,
+				Reason:   ReasonHeaderValueMismatch,
+				Severity: SeverityMedium,
+				Expected: name + ": " + bp.HeaderValues[name],
+				Observed: name + ": " + v,
+			}
+		}
+	}
+	if len(bp.OptionalHeaders) == 0 {
+		return nil
+	}
+	names := slices.Concat(bp.RequiredHeaders, bp.OptionalHeaders)
+	for _, headers := range bp.ProtocolHeaders {
+		names = append(names, headers...)
+	}
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[http.CanonicalHeaderKey(name)] = true
+	}
+	var unexpected []string
+	for name := range r.Header {
+		if !known[name] {
+			unexpected = append(unexpected, name)
+		}
+	}
+	if len(unexpected) == 0 {
+		return nil
+	}
+	sort.Strings(unexpected)
+	return &Finding{
+		Check:    CheckRequiredHeaders,
+		Reason:   ReasonUnexpectedHeader,
+		Severity: SeverityLow,
+		Observed: strings.Join(unexpected, ","),
+	}
 }
 
-func (h HeaderChecker) validateAcceptLanguage(AcceptLanguage string) bool {
+// checkAcceptLanguage reports a finding when Accept-Language is missing or
+// contains whitespace, which browsers never send.
+func (h HeaderChecker) checkAcceptLanguage(r *http.Request) *Finding {
+	AcceptLanguage := r.Header.Get("Accept-Language")
 	// Check for the existence of the Accept-Language. No header is a clear error
 	if strings.TrimSpace(AcceptLanguage) == "" {
-		if h.logger != nil {
-			h.logger.Warn("missing Accept-Language header",
-				zap.String("Accept-Language =", AcceptLanguage),
-			)
+		return &Finding{
+			Check:    CheckAcceptLanguage,
+			Reason:   ReasonAcceptLanguageMissing,
+			Severity: SeverityMedium,
+			Observed: AcceptLanguage,
 		}
-		return true
 	}
-	for _, char := range AcceptLanguage {
-		if char == ' ' {
-			h.logger.Warn("Accept-Language header contains a space",
-				zap.String("Accept-Language =", AcceptLanguage),
-			)
-			return true
+	if strings.ContainsRune(AcceptLanguage, ' ') {
+		return &Finding{
+			Check:    CheckAcceptLanguage,
+			Reason:   ReasonAcceptLanguageWhitespace,
+			Severity: SeverityLow,
+			Observed: AcceptLanguage,
 		}
+	}
+	return nil
+}
 
+func (h HeaderChecker) checkHeaderCount(r *http.Request) *Finding {
+	result := useragent.ValidateHeaderLengthWithProfiles(r.Header, h.profile(), h.headerRanges())
+	if result.WithinSpec {
+		return nil
+	}
+	reason := ReasonHeaderCountTooHigh
+	if result.HeaderLen < result.Min {
+		reason = ReasonHeaderCountTooLow
+	}
+	return &Finding{
+		Check:    CheckHeaderCount,
+		Reason:   reason,
+		Severity: SeverityMedium,
+		Expected: fmt.Sprintf("%s: %d-%d", result.Browser, result.Min, result.Max),
+		Observed: strconv.Itoa(result.HeaderLen),
 	}
-	return false
 }
 
-func cleanHeaderValue(s string) string {
-	if unquoted, err := strconv.Unquote(s); err == nil {
-		return unquoted
+func (h HeaderChecker) checkOldBrowser(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	if h.profile().IsFirefoxESR(ua) {
+		return nil
+	}
+	if useragent.IsOldBrowserWithFloors(ua, h.VersionFloors) {
+		return &Finding{
+			Check:    CheckOldBrowser,
+			Reason:   ReasonBrowserTooOld,
+			Severity: SeverityMedium,
+			Observed: ua,
+		}
 	}
-	return s
+	return h.checkReleaseFloor(ua)
 }
-func hasOuterSpaces(s string) bool {
-	return s != strings.TrimSpace(s)
+
+// checkReleaseFloor reports a browser that is more releases behind its latest
+// release, or was superseded longer ago, than the release floor allows.
+func (h HeaderChecker) checkReleaseFloor(ua string) *Finding {
+	floor := h.ReleaseFloor
+	if floor == nil {
+		return nil
+	}
+	key, version, ok := useragent.FloorKey(ua)
+	if !ok {
+		return nil
+	}
+	now := h.clock()
+	if latest, ok := h.release().Latest(key, now); ok && floor.Releases > 0 && version < latest-floor.Releases {
+		return &Finding{
+			Check:    CheckOldBrowser,
+			Reason:   ReasonBrowserTooOld,
+			Severity: SeverityMedium,
+			Expected: fmt.Sprintf("%s %d or newer, latest %d", key, latest-floor.Releases, latest),
+			Observed: ua,
+		}
+	}
+	if at, ok := h.release().SupersededAt(key, version); ok && floor.Days > 0 && now.Sub(at) > time.Duration(floor.Days)*24*time.Hour {
+		return &Finding{
+			Check:    CheckOldBrowser,
+			Reason:   ReasonBrowserTooOld,
+			Severity: SeverityMedium,
+			Expected: fmt.Sprintf("%s superseded less than %d days ago", key, floor.Days),
+			Observed: fmt.Sprintf("%s %d superseded on %s", key, version, at.Format(time.DateOnly)),
+		}
+	}
+	return nil
 }
 
-// ValidateClientHintWindowsPlatformVersion returns true if either:
-// - platform is not Windows (no check on version), we need to research how to validate other parts
-// - platform is Windows AND version is exactly "19.0.0".
-//
-// Returns false if platform is Windows but version is missing/incorrect.
-func ValidateClientHintWindowsPlatformVersion(platform string, platformVersion string) bool {
+// checkUnreleasedVersion reports a browser version that is further ahead of
+// the latest release than pre-release channels go. Past the end of the
+// release data the latest release is projected from the release cadence.
+func (h HeaderChecker) checkUnreleasedVersion(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	key, version, ok := useragent.FloorKey(ua)
+	if !ok {
+		return nil
+	}
+	latest, ok := h.release().Projected(key, h.clock())
+	if !ok || version <= latest+h.futureReleases() {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckUnreleasedVersion,
+		Reason:   ReasonBrowserUnreleased,
+		Severity: SeverityHigh,
+		Expected: fmt.Sprintf("%s %d or older, latest %d", key, latest+h.futureReleases(), latest),
+		Observed: ua,
+	}
+}
 
-	if platform != strings.TrimSpace(platform) || platformVersion != strings.TrimSpace(platformVersion) {
-		fmt.Println("DEBUG platform or version has illegal surrounding whitespace")
-		return false
+// checkAcceptCharset reports the Accept-Charset header, which browsers no longer send.
+func (h HeaderChecker) checkAcceptCharset(r *http.Request) *Finding {
+	acceptCharset := r.Header.Get("Accept-Charset")
+	if acceptCharset == "" {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckAcceptCharset,
+		Reason:   ReasonAcceptCharsetPresent,
+		Severity: SeverityHigh,
+		Observed: acceptCharset,
 	}
-	platformlocal := cleanHeaderValue(platform)
-	versionlocal := cleanHeaderValue(platformVersion)
+}
 
-	fmt.Println("DEBUG unquoted platform:", fmt.Sprintf("%q", platformlocal))
-	fmt.Println("DEBUG unquoted version :", fmt.Sprintf("%q", versionlocal))
-	if platformlocal == "" {
-		fmt.Println("DEBUG no platform atall return false")
-		return false
+func (h HeaderChecker) checkUAReduction(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	if h.profile().ValidateReduction(ua) {
+		return nil
 	}
-	if platformlocal != "\"Windows\"" {
-		fmt.Println("DEBUG platform is not Windows → return true")
-		return true
+	return &Finding{
+		Check:    CheckUAReduction,
+		Reason:   ReasonUANotReduced,
+		Severity: SeverityMedium,
+		Observed: ua,
 	}
+}
 
-	if versionlocal == "19.0.0" {
-		fmt.Println("DEBUG Windows + version == 19.0.0 → return true")
-		return true
+func (h HeaderChecker) checkSecChUaBrand(r *http.Request) *Finding {
+	if !reChromeUA.MatchString(r.Header.Get("User-Agent")) {
+		return nil
+	}
+	secChUa := r.Header.Get("Sec-Ch-Ua")
+	if !h.profile().IsBotFromSecChUa(secChUa) {
+		return nil
 	}
+	return &Finding{
+		Check:    CheckSecChUaBrand,
+		Reason:   ReasonSecChUaUnknownBrand,
+		Severity: SeverityHigh,
+		Observed: secChUa,
+	}
+}
 
-	fmt.Println("DEBUG Windows + version != 19.0.0 → return false")
-	return false
+// hintString returns the sf-string value of the client hint name, or "" when
+// the request does not send it. ok is false when the value is malformed,
+// which client_hint_syntax reports.
+func hintString(r *http.Request, name string) (value string, ok bool) {
+	v := r.Header.Get(name)
+	if v == "" {
+		return "", true
+	}
+	value, err := useragent.ParseHintString(v)
+	return value, err == nil
 }
 
-// ServeHTTP inspects the headers and then calls the next handler.
-func (h HeaderChecker) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
-	h.logRequest(r)
-	// Simple “is this Firefox at all?” check
-	var reFirefox = regexp.MustCompile(`Firefox/\d+\.\d+`)
-	var reChrome = regexp.MustCompile(`Chrome/\d+\.\d+`)
-	botdetected := false
-	if h.validateSecFetchRequests(r) {
-		if h.logger != nil {
-			h.logger.Warn("There is something strange with the Sec-Fetch headers",
-				zap.String("Sec-Fetch-Site", r.Header.Get("Sec-Fetch-Site")),
-				zap.String("Sec-Fetch-Mode", r.Header.Get("Sec-Fetch-Mode")),
-				zap.String("Sec-Fetch-Dest", r.Header.Get("Sec-Fetch-Dest")),
-			)
+// checkClientHintSyntax reports a UA client hint that is not a valid
+// structured field (RFC 8941) of its type. Browsers build these headers with
+// a serializer; hand-written spoofs often get the quoting wrong.
+func (h HeaderChecker) checkClientHintSyntax(r *http.Request) *Finding {
+	for _, name := range useragent.ClientHintNames() {
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if err := useragent.ValidateClientHint(name, value); err != nil {
+			t, _ := useragent.ClientHintType(name)
+			return &Finding{
+				Check:    CheckClientHintSyntax,
+				Reason:   ReasonClientHintMalformed,
+				Severity: SeverityHigh,
+				Expected: name + ": " + t,
+				Observed: name + ": " + value,
+			}
 		}
 	}
+	return nil
+}
+func hasOuterSpaces(s string) bool {
+	return s != strings.TrimSpace(s)
+}
 
-	if h.validateAcceptLanguage(r.Header.Get("Accept-Language")) {
-		if h.logger != nil {
-			h.logger.Warn("missing Accept-Language header")
-		}
+// ValidateClientHintWindowsPlatformVersion reports whether the
+// Sec-CH-UA-Platform and Sec-CH-UA-Platform-Version headers fit a Windows
+// release Chromium runs on. Other platforms pass; a missing or malformed
+// platform fails.
+func ValidateClientHintWindowsPlatformVersion(platform string, platformVersion string) bool {
+	_, _, err := parseWindowsHints(platform, platformVersion)
+	return err == nil
+}
+
+// parseWindowsHints parses the Windows version in the client hints. windows
+// is false for other platforms, which are not checked.
+func parseWindowsHints(platform, platformVersion string) (v useragent.WindowsVersion, windows bool, err error) {
+	if hasOuterSpaces(platform) || hasOuterSpaces(platformVersion) {
+		return v, false, fmt.Errorf("client hints with surrounding whitespace")
 	}
-	if IsDevtoolsPath(r) {
-		if h.logger != nil {
-			h.logger.Warn("Someone is using the devtools block request",
-				zap.String("Pat that was excest", r.URL.Path),
-			)
-		}
+	name, err := useragent.ParseHintString(platform)
+	if err != nil {
+		return v, false, fmt.Errorf("Sec-CH-UA-Platform: %v", err)
 	}
-	HeaderCheckResult := useragent.ValidateHeaderLength(r.Header)
-	if HeaderCheckResult.WithinSpec == false {
-		if h.logger != nil {
-			h.logger.Warn("The browser requested more or to less headers then normal",
-				zap.String("Reason", HeaderCheckResult.Reason),
-				zap.String("Browser", string(HeaderCheckResult.Browser)),
-				zap.Int("headerlengt", HeaderCheckResult.HeaderLen),
-			)
-		}
-		botdetected = true
+	// A platform quoted twice, such as "\"Windows\"", is valid syntax but no
+	// browser sends it.
+	if strings.Contains(name, `"`) {
+		return v, false, fmt.Errorf("Sec-CH-UA-Platform: %s still has quotes after parsing", platform)
+	}
+	if name != "Windows" {
+		return v, false, nil
+	}
+	version, err := useragent.ParseHintString(platformVersion)
+	if err != nil {
+		return v, true, fmt.Errorf("Sec-CH-UA-Platform-Version: %v", err)
 	}
+	v, err = useragent.ParseWindowsPlatformVersion(version)
+	return v, true, err
+}
+
+// checkWindowsPlatformVersion reports a Windows platform version no Windows
+// release Chromium runs on reports, or a Windows platform without the
+// reduced Windows token in the User-Agent.
+func (h HeaderChecker) checkWindowsPlatformVersion(r *http.Request) *Finding {
 	ua := r.Header.Get("User-Agent")
-	reduced := useragent.ValidateReduction(ua)
-	if useragent.IsOldBrowser(ua) {
-		if h.logger != nil {
-			h.logger.Warn("request from old browser based on user agent ",
-				zap.String("user agent=", ua),
-			)
-		}
-		botdetected = true
+	if !reChromeUA.MatchString(ua) {
+		return nil
 	}
-	//validate the presence of the accept-charset header which is depricated by default
-	if r.Header.Get("Accept-Charset") != "" {
-		if h.logger != nil {
-			h.logger.Warn("Accept-Charset header detected which is depricated and therefor a bot is used",
-				zap.String("Accept-header=", r.Header.Get("Accept-Charset")),
-			)
+	platform := r.Header.Get("Sec-CH-UA-Platform")
+	platformVersion := r.Header.Get("Sec-CH-UA-Platform-Version")
+	v, windows, err := parseWindowsHints(platform, platformVersion)
+	if windows {
+		if platformVersion == "" {
+			if bp, _ := h.profileFor(h.chromeFamily(r), ua); bp.OptionalHint("Sec-Ch-Ua-Platform-Version") {
+				return nil
+			}
+		}
+		if err == nil && !strings.Contains(ua, string(useragent.PlatformWindows)) {
+			err = fmt.Errorf("Windows platform without the %q User-Agent token", useragent.PlatformWindows)
 		}
-		botdetected = true
-	}
-	if reduced == false {
 		if h.logger != nil {
-			h.logger.Warn("User agent reduction error",
-				zap.String("user agent=", ua),
+			h.logger.Debug("windows platform version",
+				zap.String("platform_version", platformVersion),
+				zap.Int("windows", v.Windows),
+				zap.String("release", v.Release),
+				zap.Error(err),
 			)
 		}
-		botdetected = true
 	}
-	if reFirefox.MatchString(ua) {
-		if h.validateFireFoxAcceptHeader(r) == false {
-			if h.logger != nil {
-				h.logger.Warn("error in firefox acceptheader")
-			}
-			botdetected = true
-		}
+	if err == nil {
+		return nil
 	}
-	if reChrome.MatchString(ua) {
-		if CheckSecCHDeviceMemoryequalto8(r) == false {
-			if h.logger != nil {
-				h.logger.Warn("Memory of device lower then 8 high bot change")
-			}
-			botdetected = true
-		}
-		if ValidateClientHintWindowsPlatformVersion(r.Header.Get("Sec-CH-UA-Platform"), r.Header.Get("Sec-CH-UA-Platform-Version")) == false {
-			if h.logger != nil {
-				h.logger.Warn("Windows Platform version client hint is of (could be a patched puppeteer or some other system) ")
-			}
-			botdetected = true
-		}
-		if h.crossReferenceClientHintHeaders(r) == false {
-			if h.logger != nil {
-				h.logger.Warn("CrossReference Clienthint errors ")
-			}
-			botdetected = true
-		}
-		if h.validateChromeAcceptHeader(r) == false {
-			if h.logger != nil {
-				h.logger.Warn("error in Chrome acceptheader")
-			}
-			botdetected = true
-		}
-		secChUaPlatform := r.Header.Get("Sec-Ch-Ua-Platform")
-		secChUa := r.Header.Get("Sec-Ch-Ua")
-		if useragent.IsBotFromSecChUa(secChUa) {
-			if h.logger != nil {
-				h.logger.Warn("CLient hint Ua contains bot related data",
-					zap.String("user agent=", secChUa),
-				)
-			}
-			botdetected = true
-		}
-		if strings.Contains(strings.ToLower(secChUaPlatform), "linux") {
-			if h.ValidateSecChUaPlatformLinux(r) == false {
-				if h.logger != nil {
-					h.logger.Warn("CLient hint platform linux contains an error",
-						zap.String("SecCHuser agent=", secChUa))
-				}
-				botdetected = true
-			}
-		}
+	return &Finding{
+		Check:    CheckWindowsPlatformVersion,
+		Reason:   ReasonWindowsPlatformVersion,
+		Severity: SeverityMedium,
+		Expected: err.Error(),
+		Observed: fmt.Sprintf("platform=%s version=%s", platform, platformVersion),
 	}
-	if validateAcceptHeader(r.Header.Values("Accept")) && botdetected == false {
-		w.Header().Set("SecureHeader", "true")
-	} else {
-		w.Header().Set("SecureHeader", "false")
+}
+
+// checkPlatformVersion reports a Sec-CH-UA-Platform-Version that does not fit
+// the platform rule of the profile data: a macOS or ChromeOS version no
+// release reports or one copied from the frozen User-Agent, an Android
+// version without a real model, or a non-empty version on Linux.
+func (h HeaderChecker) checkPlatformVersion(r *http.Request) *Finding {
+	ua := r.Header.Get("User-Agent")
+	if !reChromeUA.MatchString(ua) {
+		return nil
+	}
+	err := h.profile().ValidatePlatformVersion(r.Header)
+	if err == nil {
+		return nil
+	}
+	return &Finding{
+		Check:    CheckPlatformVersion,
+		Reason:   ReasonPlatformVersion,
+		Severity: SeverityMedium,
+		Expected: err.Error(),
+		Observed: fmt.Sprintf("platform=%s version=%s", r.Header.Get("Sec-CH-UA-Platform"), r.Header.Get("Sec-CH-UA-Platform-Version")),
+	}
+}
+
+// ServeHTTP inspects the headers and then calls the next handler.
+func (h HeaderChecker) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	h.logRequest(r)
+	verdict := h.Evaluate(r)
+	publishVerdict(r, verdict)
+	if handled, err := h.enforce(w, r, h.actionFor(verdict), verdict); handled {
+		return err
 	}
+	h.stripVerdictHeaders(r)
+	h.signVerdict(r, verdict)
 	return next.ServeHTTP(w, r)
 }
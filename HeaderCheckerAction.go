@@ -0,0 +1,150 @@
+package CaddyHeaderVerification
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// Action types. They are ordered from least to most severe.
+const (
+	// ActionLog passes the request on and only logs the verdict.
+	ActionLog = "log"
+	// ActionTag passes the request on and sets the verdict response headers.
+	ActionTag = "tag"
+	// ActionReject responds with a status code and body without calling the next handler.
+	ActionReject = "reject"
+	// ActionError returns a caddyhttp.Error so handle_errors routes can render the response.
+	ActionError = "error"
+	// ActionAbort closes the connection without a response.
+	ActionAbort = "abort"
+)
+
+var actionSeverity = map[string]int{
+	ActionLog:    0,
+	ActionTag:    1,
+	ActionReject: 2,
+	ActionError:  2,
+	ActionAbort:  3,
+}
+
+// DefaultActionStatus is the status code used by reject and error when none is configured.
+const DefaultActionStatus = http.StatusForbidden
+
+// Action describes what the handler does with a request.
+type Action struct {
+	// Type is one of log, tag, reject, error or abort.
+	Type string `json:"type"`
+
+	// StatusCode is the response status for reject and error. Default: 403.
+	StatusCode int `json:"status_code,omitempty"`
+
+	// Body is the response body for reject.
+	Body string `json:"body,omitempty"`
+}
+
+var defaultAction = &Action{Type: ActionTag}
+
+// status returns the configured status code or DefaultActionStatus.
+func (a *Action) status() int {
+	if a.StatusCode != 0 {
+		return a.StatusCode
+	}
+	return DefaultActionStatus
+}
+
+// validate checks a single action.
+func (a *Action) validate() error {
+	if _, ok := actionSeverity[a.Type]; !ok {
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	if a.StatusCode != 0 && (a.StatusCode < 100 || a.StatusCode > 999) {
+		return fmt.Errorf("action %s: invalid status code %d", a.Type, a.StatusCode)
+	}
+	if a.Type != ActionReject && a.Type != ActionError && a.StatusCode != 0 {
+		return fmt.Errorf("action %s does not take a status code", a.Type)
+	}
+	if a.Type != ActionReject && a.Body != "" {
+		return fmt.Errorf("action %s does not take a body", a.Type)
+	}
+	return nil
+}
+
+// actionFor picks the action for a scored request: the action of its class,
+// replaced by the action of a failing check when that one is more severe.
+func (h HeaderChecker) actionFor(verdict Verdict) *Action {
+	action := defaultAction
+	if a, ok := h.Actions[string(verdict.Class)]; ok && a != nil {
+		action = a
+	}
+	for _, id := range verdict.FailedChecks() {
+		c, ok := h.Checks[id]
+		if !ok || c == nil || c.Action == nil {
+			continue
+		}
+		if actionSeverity[c.Action.Type] > actionSeverity[action.Type] {
+			action = c.Action
+		}
+	}
+	return action
+}
+
+// enforce carries out action. It returns handled true when the next handler
+// must not be called.
+func (h HeaderChecker) enforce(w http.ResponseWriter, r *http.Request, action *Action, verdict Verdict) (handled bool, err error) {
+	if h.logger != nil && action.Type != ActionTag {
+		h.logger.Info("bot verdict",
+			zap.String("action", action.Type),
+			zap.String("class", string(verdict.Class)),
+			zap.Int("score", verdict.Score),
+			zap.Strings("reasons", verdict.Reasons()),
+			zap.Int("shadow_score", verdict.ShadowScore),
+		)
+	}
+
+	switch action.Type {
+	case ActionLog:
+		return false, nil
+	case ActionReject:
+		h.setResponseHeaders(w.Header(), verdict)
+		w.WriteHeader(action.status())
+		if action.Body != "" {
+			_, err = w.Write([]byte(action.Body))
+		}
+		return true, err
+	case ActionError:
+		return true, caddyhttp.Error(action.status(), fmt.Errorf("headerchecker: request classified as %s (score %d)", verdict.Class, verdict.Score))
+	case ActionAbort:
+		panic(http.ErrAbortHandler)
+	default:
+		h.setResponseHeaders(w.Header(), verdict)
+		return false, nil
+	}
+}
+
+// unmarshalAction parses "<type> [<status>] [<body>]" from the remaining arguments on the line.
+func unmarshalAction(d *caddyfile.Dispenser) (*Action, error) {
+	if !d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	action := &Action{Type: d.Val()}
+	if d.NextArg() {
+		status, err := strconv.Atoi(d.Val())
+		if err != nil {
+			return nil, d.Errf("invalid status code %q: %v", d.Val(), err)
+		}
+		action.StatusCode = status
+	}
+	if d.NextArg() {
+		action.Body = d.Val()
+	}
+	if d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	return action, nil
+}
@@ -0,0 +1,771 @@
+package CaddyHeaderVerification
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+// Interface guards
+var (
+	_ caddy.Provisioner     = (*HeaderChecker)(nil)
+	_ caddy.Validator       = (*HeaderChecker)(nil)
+	_ caddyfile.Unmarshaler = (*HeaderChecker)(nil)
+)
+
+// Check IDs. These are the names used for the checks in the Caddyfile and JSON config.
+const (
+	CheckSecFetch               = "sec_fetch"
+	CheckAcceptLanguage         = "accept_language"
+	CheckDevtoolsPath           = "devtools_path"
+	CheckHeaderCount            = "header_count"
+	CheckOldBrowser             = "old_browser"
+	CheckAcceptCharset          = "accept_charset"
+	CheckUAReduction            = "ua_reduction"
+	CheckFirefoxAccept          = "firefox_accept"
+	CheckDeviceMemory           = "device_memory"
+	CheckWindowsPlatformVersion = "windows_platform_version"
+	CheckClientHintVersions     = "client_hint_versions"
+	CheckChromeAccept           = "chrome_accept"
+	CheckSecChUaBrand           = "sec_ch_ua_brand"
+	CheckLinuxPlatform          = "linux_platform"
+	CheckAcceptWildcard         = "accept_wildcard"
+	CheckAcceptEncoding         = "accept_encoding"
+	CheckRequiredHeaders        = "required_headers"
+	CheckSafariAccept           = "safari_accept"
+	CheckUAGrammar              = "ua_grammar"
+	CheckMobileHints            = "mobile_hints"
+	CheckTorBrowser             = "tor_browser"
+	CheckInAppBrowser           = "in_app_browser"
+	CheckAppPackage             = "app_package"
+	CheckUnreleasedVersion      = "unreleased_version"
+	CheckClientHintSyntax       = "client_hint_syntax"
+	CheckGreaseBrand            = "grease_brand"
+	CheckPlatformVersion        = "platform_version"
+	CheckPlatformToken          = "platform_token"
+)
+
+// Tor Browser policies.
+const (
+	// TorPolicyAllow evaluates Tor Browser like any other browser.
+	TorPolicyAllow = "allow"
+	// TorPolicyChallenge classes Tor Browser at least suspicious.
+	TorPolicyChallenge = "challenge"
+	// TorPolicyBlock classes Tor Browser as bot. It requires TorBlockESR.
+	TorPolicyBlock = "block"
+)
+
+// In-app browser policies. They apply to the in-app browsers of social apps
+// and to other apps that embed Android WebView.
+const (
+	// InAppPolicyAllow evaluates in-app browsers against their own profiles.
+	InAppPolicyAllow = "allow"
+	// InAppPolicyChallenge classes in-app browsers at least suspicious.
+	InAppPolicyChallenge = "challenge"
+	// InAppPolicyBlock classes in-app browsers as bot.
+	InAppPolicyBlock = "block"
+)
+
+// Keys for the expected Accept header values.
+const (
+	AcceptChrome             = "chrome"
+	AcceptChromeImage        = "chrome_image"
+	AcceptChromeAndroid      = "chrome_android"
+	AcceptChromeAndroidImage = "chrome_android_image"
+	AcceptEdge               = "edge"
+	AcceptEdgeImage          = "edge_image"
+	AcceptEdgeWebView2       = "edge_webview2"
+	AcceptEdgeWebView2Image  = "edge_webview2_image"
+	AcceptEdgeAndroid        = "edge_android"
+	AcceptEdgeAndroidImage   = "edge_android_image"
+	AcceptBrave              = "brave"
+	AcceptBraveImage         = "brave_image"
+	AcceptOpera              = "opera"
+	AcceptOperaImage         = "opera_image"
+	AcceptVivaldi            = "vivaldi"
+	AcceptVivaldiImage       = "vivaldi_image"
+	AcceptSamsung            = "samsung"
+	AcceptSamsungImage       = "samsung_image"
+	AcceptYandex             = "yandex"
+	AcceptYandexImage        = "yandex_image"
+	AcceptFirefox            = "firefox"
+	AcceptFirefoxImage       = "firefox_image"
+	AcceptSafari             = "safari"
+	AcceptSafariImage        = "safari_image"
+	AcceptChromeIOS          = "chrome_ios"
+	AcceptChromeIOSImage     = "chrome_ios_image"
+	AcceptFirefoxIOS         = "firefox_ios"
+	AcceptFirefoxIOSImage    = "firefox_ios_image"
+	AcceptEdgeIOS            = "edge_ios"
+	AcceptEdgeIOSImage       = "edge_ios_image"
+)
+
+// acceptKeys maps the keys of AcceptHeaders to the browser and destination
+// of the profile value they override.
+var acceptKeys = map[string]struct {
+	browser     useragent.BrowserKind
+	destination string
+}{
+	AcceptChrome:             {useragent.BrowserChrome, useragent.DestinationDocument},
+	AcceptChromeImage:        {useragent.BrowserChrome, useragent.DestinationImage},
+	AcceptChromeAndroid:      {useragent.BrowserChromeAndroid, useragent.DestinationDocument},
+	AcceptChromeAndroidImage: {useragent.BrowserChromeAndroid, useragent.DestinationImage},
+	AcceptEdge:               {useragent.BrowserEdge, useragent.DestinationDocument},
+	AcceptEdgeImage:          {useragent.BrowserEdge, useragent.DestinationImage},
+	AcceptEdgeWebView2:       {useragent.BrowserEdgeWebView2, useragent.DestinationDocument},
+	AcceptEdgeWebView2Image:  {useragent.BrowserEdgeWebView2, useragent.DestinationImage},
+	AcceptEdgeAndroid:        {useragent.BrowserEdgeAndroid, useragent.DestinationDocument},
+	AcceptEdgeAndroidImage:   {useragent.BrowserEdgeAndroid, useragent.DestinationImage},
+	AcceptBrave:              {useragent.BrowserBrave, useragent.DestinationDocument},
+	AcceptBraveImage:         {useragent.BrowserBrave, useragent.DestinationImage},
+	AcceptOpera:              {useragent.BrowserOpera, useragent.DestinationDocument},
+	AcceptOperaImage:         {useragent.BrowserOpera, useragent.DestinationImage},
+	AcceptVivaldi:            {useragent.BrowserVivaldi, useragent.DestinationDocument},
+	AcceptVivaldiImage:       {useragent.BrowserVivaldi, useragent.DestinationImage},
+	AcceptSamsung:            {useragent.BrowserSamsung, useragent.DestinationDocument},
+	AcceptSamsungImage:       {useragent.BrowserSamsung, useragent.DestinationImage},
+	AcceptYandex:             {useragent.BrowserYandex, useragent.DestinationDocument},
+	AcceptYandexImage:        {useragent.BrowserYandex, useragent.DestinationImage},
+	AcceptFirefox:            {useragent.BrowserFirefox, useragent.DestinationDocument},
+	AcceptFirefoxImage:       {useragent.BrowserFirefox, useragent.DestinationImage},
+	AcceptSafari:             {useragent.BrowserSafari, useragent.DestinationDocument},
+	AcceptSafariImage:        {useragent.BrowserSafari, useragent.DestinationImage},
+	AcceptChromeIOS:          {useragent.BrowserChromeIOS, useragent.DestinationDocument},
+	AcceptChromeIOSImage:     {useragent.BrowserChromeIOS, useragent.DestinationImage},
+	AcceptFirefoxIOS:         {useragent.BrowserFirefoxIOS, useragent.DestinationDocument},
+	AcceptFirefoxIOSImage:    {useragent.BrowserFirefoxIOS, useragent.DestinationImage},
+	AcceptEdgeIOS:            {useragent.BrowserEdgeIOS, useragent.DestinationDocument},
+	AcceptEdgeIOSImage:       {useragent.BrowserEdgeIOS, useragent.DestinationImage},
+}
+
+// validDeviceMemory are the values browsers are allowed to send in Sec-CH-Device-Memory.
+var validDeviceMemory = map[string]bool{
+	"0.25": true, "0.5": true, "1": true, "2": true, "4": true, "8": true,
+}
+
+// DefaultFutureReleases is the number of major versions a browser may be
+// ahead of its latest release: beta, dev and canary or nightly.
+const DefaultFutureReleases = 3
+
+// ReleaseFloor derives version floors from the release data, so they move
+// with every release. A browser that fails either limit is too old.
+type ReleaseFloor struct {
+	// Releases is the number of major versions a browser may be behind its
+	// latest release. Zero means no limit.
+	Releases int `json:"releases,omitempty"`
+
+	// Days is the number of days a major version is accepted after the next
+	// one was released. Zero means no limit.
+	Days int `json:"days,omitempty"`
+}
+
+// DefaultResponseHeader is the response header that carries the verdict.
+const DefaultResponseHeader = "SecureHeader"
+
+// CheckConfig holds the settings of a single check.
+type CheckConfig struct {
+	// Disabled turns the check off.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Weight is the score the check adds when it fails, 0-100.
+	Weight *int `json:"weight,omitempty"`
+
+	// Action is taken when the check fails and is more severe than the action of the verdict class.
+	Action *Action `json:"action,omitempty"`
+
+	// ReportOnly logs and counts the findings of the check but leaves them out of the verdict.
+	ReportOnly bool `json:"report_only,omitempty"`
+}
+
+// enabled reports whether the check with the given ID should run.
+func (h HeaderChecker) enabled(id string) bool {
+	c, ok := h.Checks[id]
+	return !ok || c == nil || !c.Disabled
+}
+
+// reportOnly reports whether findings of check id are left out of the verdict.
+func (h HeaderChecker) reportOnly(id string) bool {
+	if h.ReportOnly {
+		return true
+	}
+	c, ok := h.Checks[id]
+	return ok && c != nil && c.ReportOnly
+}
+
+// profile returns the loaded browser profiles, or the embedded defaults.
+func (h HeaderChecker) profile() *useragent.Profiles {
+	if h.profiles != nil {
+		return h.profiles
+	}
+	return useragent.DefaultProfiles()
+}
+
+// release returns the loaded release data, or the embedded defaults.
+func (h HeaderChecker) release() *useragent.Releases {
+	if h.releases != nil {
+		return h.releases
+	}
+	return useragent.DefaultReleases()
+}
+
+// clock returns the current time.
+func (h HeaderChecker) clock() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+// futureReleases returns how many major versions a browser may be ahead of
+// its latest release.
+func (h HeaderChecker) futureReleases() int {
+	if h.FutureReleases != nil {
+		return *h.FutureReleases
+	}
+	return DefaultFutureReleases
+}
+
+// acceptFor returns the expected Accept value of browser for destination,
+// taking AcceptHeaders overrides into account.
+func (h HeaderChecker) acceptFor(browser useragent.BrowserKind, bp useragent.BrowserProfile, destination string) string {
+	for key, target := range acceptKeys {
+		if target.browser != browser || target.destination != destination {
+			continue
+		}
+		if v, ok := h.AcceptHeaders[key]; ok {
+			return v
+		}
+	}
+	return bp.Accept[destination]
+}
+
+// acceptValues returns every accepted Accept value of browser for
+// destination: the configured value, or the profile value and its variants.
+func (h HeaderChecker) acceptValues(browser useragent.BrowserKind, bp useragent.BrowserProfile, destination string) []string {
+	accept := h.acceptFor(browser, bp, destination)
+	switch {
+	case accept == "":
+		return nil
+	case accept != bp.Accept[destination]:
+		return []string{accept}
+	}
+	return append([]string{accept}, bp.AcceptVariants[destination]...)
+}
+
+// deviceMemory returns the accepted Sec-CH-Device-Memory values.
+func (h HeaderChecker) deviceMemory(bp useragent.BrowserProfile) []string {
+	if len(h.DeviceMemory) > 0 {
+		return h.DeviceMemory
+	}
+	return bp.DeviceMemory()
+}
+
+// headerRanges converts the configured header counts to useragent ranges.
+func (h HeaderChecker) headerRanges() map[useragent.BrowserKind]useragent.HeaderRange {
+	if len(h.HeaderCounts) == 0 {
+		return nil
+	}
+	ranges := make(map[useragent.BrowserKind]useragent.HeaderRange, len(h.HeaderCounts))
+	for browser, r := range h.HeaderCounts {
+		ranges[useragent.BrowserKind(browser)] = r
+	}
+	return ranges
+}
+
+// responseHeader returns the name of the verdict response header, or "" when disabled.
+func (h HeaderChecker) responseHeader() string {
+	if h.DisableResponseHeader {
+		return ""
+	}
+	if h.ResponseHeader != "" {
+		return h.ResponseHeader
+	}
+	return DefaultResponseHeader
+}
+
+// torPolicy returns the configured Tor Browser policy. Default: allow.
+func (h HeaderChecker) torPolicy() string {
+	if h.TorPolicy != "" {
+		return h.TorPolicy
+	}
+	return TorPolicyAllow
+}
+
+// inAppPolicy returns the configured in-app browser policy. Default: allow.
+func (h HeaderChecker) inAppPolicy() string {
+	if h.InAppPolicy != "" {
+		return h.InAppPolicy
+	}
+	return InAppPolicyAllow
+}
+
+// policyWeight is the default weight of a check that enforces a browser
+// policy, tor_policy or in_app_policy: the suspicious threshold for challenge
+// and the maximum score for block. Both policies use the same values.
+func (h HeaderChecker) policyWeight(policy string) int {
+	switch policy {
+	case TorPolicyChallenge:
+		return h.suspiciousThreshold()
+	case TorPolicyBlock:
+		return MaxScore
+	}
+	return 0
+}
+
+// Validate checks the configuration for unknown names and contradictory settings.
+func (h *HeaderChecker) Validate() error {
+	checks := slices.Sorted(maps.Keys(h.Checks))
+	for _, id := range checks {
+		if !isCheckID(id) {
+			return fmt.Errorf("unknown check %q", id)
+		}
+	}
+
+	for _, id := range checks {
+		c := h.Checks[id]
+		if c == nil || c.Weight == nil {
+			continue
+		}
+		if *c.Weight < 0 || *c.Weight > MaxScore {
+			return fmt.Errorf("check %s: weight %d is outside 0-%d", id, *c.Weight, MaxScore)
+		}
+		if c.Disabled {
+			return fmt.Errorf("check %s: weight is set but the check is disabled", id)
+		}
+	}
+
+	for _, id := range checks {
+		c := h.Checks[id]
+		if c == nil || c.Action == nil {
+			continue
+		}
+		if err := c.Action.validate(); err != nil {
+			return fmt.Errorf("check %s: %v", id, err)
+		}
+		if c.Disabled {
+			return fmt.Errorf("check %s: action is set but the check is disabled", id)
+		}
+		if h.reportOnly(id) {
+			return fmt.Errorf("check %s: action is set but the check is report-only", id)
+		}
+	}
+
+	for _, id := range checks {
+		if c := h.Checks[id]; c != nil && c.Disabled && c.ReportOnly {
+			return fmt.Errorf("check %s: report_only is set but the check is disabled", id)
+		}
+	}
+
+	for _, class := range slices.Sorted(maps.Keys(h.Actions)) {
+		a := h.Actions[class]
+		switch VerdictClass(class) {
+		case ClassHuman, ClassSuspicious, ClassBot:
+		default:
+			return fmt.Errorf("action: unknown verdict class %q", class)
+		}
+		if a == nil {
+			continue
+		}
+		if err := a.validate(); err != nil {
+			return fmt.Errorf("action %s: %v", class, err)
+		}
+		if VerdictClass(class) == ClassHuman && a.Type != ActionLog && a.Type != ActionTag {
+			return fmt.Errorf("action %s: requests classed human can only be logged or tagged", class)
+		}
+		if h.ReportOnly && a.Type != ActionLog && a.Type != ActionTag {
+			return fmt.Errorf("action %s: the handler is report-only, so requests are never classed %s", class, class)
+		}
+	}
+
+	suspicious, bot := h.suspiciousThreshold(), h.botThreshold()
+	if suspicious < 1 || suspicious > MaxScore || bot < 1 || bot > MaxScore {
+		return fmt.Errorf("score thresholds must be within 1-%d", MaxScore)
+	}
+	if suspicious > bot {
+		return fmt.Errorf("suspicious threshold %d is greater than bot threshold %d", suspicious, bot)
+	}
+
+	for _, browser := range slices.Sorted(maps.Keys(h.HeaderCounts)) {
+		r := h.HeaderCounts[browser]
+		if _, ok := h.profile().Browsers[useragent.BrowserKind(browser)]; !ok {
+			return fmt.Errorf("header_count: unknown browser %q", browser)
+		}
+		if r.Min < 0 || r.Max < 0 {
+			return fmt.Errorf("header_count %s: counts must not be negative", browser)
+		}
+		if r.Min > r.Max {
+			return fmt.Errorf("header_count %s: min %d is greater than max %d", browser, r.Min, r.Max)
+		}
+	}
+	if len(h.HeaderCounts) > 0 && !h.enabled(CheckHeaderCount) {
+		return fmt.Errorf("header_count ranges are configured but check %q is disabled", CheckHeaderCount)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(h.VersionFloors)) {
+		v := h.VersionFloors[key]
+		if !useragent.IsFloorKey(key) {
+			return fmt.Errorf("version_floor: unknown browser %q", key)
+		}
+		if v < 0 {
+			return fmt.Errorf("version_floor %s: version must not be negative", key)
+		}
+	}
+	if len(h.VersionFloors) > 0 && !h.enabled(CheckOldBrowser) {
+		return fmt.Errorf("version floors are configured but check %q is disabled", CheckOldBrowser)
+	}
+	if f := h.ReleaseFloor; f != nil {
+		if f.Releases < 0 || f.Days < 0 {
+			return fmt.Errorf("release_floor: limits must not be negative")
+		}
+		if !h.enabled(CheckOldBrowser) {
+			return fmt.Errorf("a release floor is configured but check %q is disabled", CheckOldBrowser)
+		}
+	}
+	if h.FutureReleases != nil && *h.FutureReleases < 0 {
+		return fmt.Errorf("future_releases: %d must not be negative", *h.FutureReleases)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(h.AcceptHeaders)) {
+		v := h.AcceptHeaders[key]
+		if _, ok := acceptKeys[key]; !ok {
+			return fmt.Errorf("accept: unknown key %q", key)
+		}
+		if strings.TrimSpace(v) == "" {
+			return fmt.Errorf("accept %s: value must not be empty", key)
+		}
+	}
+
+	for _, v := range h.DeviceMemory {
+		if !validDeviceMemory[v] {
+			return fmt.Errorf("device_memory: %q is not a valid Sec-CH-Device-Memory value", v)
+		}
+	}
+	if len(h.DeviceMemory) > 0 && !h.enabled(CheckDeviceMemory) {
+		return fmt.Errorf("device_memory values are configured but check %q is disabled", CheckDeviceMemory)
+	}
+
+	if h.DisableResponseHeader && h.ResponseHeader != "" {
+		return fmt.Errorf("response_header %q is set but the response header is disabled", h.ResponseHeader)
+	}
+
+	switch h.torPolicy() {
+	case TorPolicyAllow, TorPolicyChallenge:
+	case TorPolicyBlock:
+		if !h.TorBlockESR {
+			return fmt.Errorf("tor_policy: block also blocks English Firefox ESR on Windows; set tor_block_esr to accept this")
+		}
+	default:
+		return fmt.Errorf("tor_policy: unknown policy %q", h.TorPolicy)
+	}
+	if h.TorBlockESR && h.torPolicy() != TorPolicyBlock {
+		return fmt.Errorf("tor_block_esr is set but tor_policy is %q", h.torPolicy())
+	}
+	switch h.inAppPolicy() {
+	case InAppPolicyAllow, InAppPolicyChallenge, InAppPolicyBlock:
+	default:
+		return fmt.Errorf("in_app_policy: unknown policy %q", h.InAppPolicy)
+	}
+	for _, pkg := range h.InAppPackages {
+		if !useragent.IsAppPackage(pkg) {
+			return fmt.Errorf("in_app_packages: %q is not an application ID", pkg)
+		}
+	}
+
+	if h.Upstream != nil {
+		if err := h.Upstream.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCaddyfile sets up the handler from Caddyfile tokens. Syntax:
+//
+//	headerchecker {
+//	    profile_file <path>
+//	    disable <check...>
+//	    check <check> {
+//	        disabled
+//	        weight <0-100>
+//	        action <log|tag|reject|error|abort> [<status>] [<body>]
+//	        report_only
+//	    }
+//	    report_only
+//	    score_thresholds <suspicious> <bot>
+//	    action <human|suspicious|bot> <log|tag|reject|error|abort> [<status>] [<body>]
+//	    header_count <browser> <min> <max>
+//	    version_floor <browser> <major>
+//	    release_file <path>
+//	    release_floor releases|days <n>
+//	    future_releases <n>
+//	    accept <key> <value>
+//	    device_memory <value...>
+//	    response_header <name>|off
+//	    tor_policy <allow|challenge|block> [esr]
+//	    in_app_policy <allow|challenge|block>
+//	    in_app_packages <package...>
+//	    upstream_header {
+//	        header <name>
+//	        key_id <id>
+//	        key <secret>
+//	        strip <header...>
+//	    }
+//	}
+func (h *HeaderChecker) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	d.Next() // consume directive name
+	if d.NextArg() {
+		return d.ArgErr()
+	}
+
+	for d.NextBlock(0) {
+		switch d.Val() {
+		case "profile_file":
+			if !d.AllArgs(&h.ProfileFile) {
+				return d.ArgErr()
+			}
+
+		case "disable":
+			ids := d.RemainingArgs()
+			if len(ids) == 0 {
+				return d.ArgErr()
+			}
+			for _, id := range ids {
+				h.checkConfig(id).Disabled = true
+			}
+
+		case "check":
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			c := h.checkConfig(d.Val())
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+			for nesting := d.Nesting(); d.NextBlock(nesting); {
+				switch d.Val() {
+				case "disabled":
+					if d.NextArg() {
+						return d.ArgErr()
+					}
+					c.Disabled = true
+				case "weight":
+					if !d.NextArg() {
+						return d.ArgErr()
+					}
+					weight, err := strconv.Atoi(d.Val())
+					if err != nil {
+						return d.Errf("invalid weight %q: %v", d.Val(), err)
+					}
+					c.Weight = &weight
+					if d.NextArg() {
+						return d.ArgErr()
+					}
+				case "report_only":
+					if d.NextArg() {
+						return d.ArgErr()
+					}
+					c.ReportOnly = true
+				case "action":
+					action, err := unmarshalAction(d)
+					if err != nil {
+						return err
+					}
+					c.Action = action
+				default:
+					return d.Errf("unrecognized check subdirective %q", d.Val())
+				}
+			}
+
+		case "report_only":
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+			h.ReportOnly = true
+
+		case "score_thresholds":
+			var suspiciousStr, botStr string
+			if !d.AllArgs(&suspiciousStr, &botStr) {
+				return d.ArgErr()
+			}
+			suspicious, err := strconv.Atoi(suspiciousStr)
+			if err != nil {
+				return d.Errf("invalid suspicious threshold %q: %v", suspiciousStr, err)
+			}
+			bot, err := strconv.Atoi(botStr)
+			if err != nil {
+				return d.Errf("invalid bot threshold %q: %v", botStr, err)
+			}
+			h.SuspiciousThreshold = &suspicious
+			h.BotThreshold = &bot
+
+		case "action":
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			class := d.Val()
+			action, err := unmarshalAction(d)
+			if err != nil {
+				return err
+			}
+			if h.Actions == nil {
+				h.Actions = make(map[string]*Action)
+			}
+			h.Actions[class] = action
+
+		case "header_count":
+			var browser, minStr, maxStr string
+			if !d.AllArgs(&browser, &minStr, &maxStr) {
+				return d.ArgErr()
+			}
+			min, err := strconv.Atoi(minStr)
+			if err != nil {
+				return d.Errf("invalid min header count %q: %v", minStr, err)
+			}
+			max, err := strconv.Atoi(maxStr)
+			if err != nil {
+				return d.Errf("invalid max header count %q: %v", maxStr, err)
+			}
+			if h.HeaderCounts == nil {
+				h.HeaderCounts = make(map[string]useragent.HeaderRange)
+			}
+			h.HeaderCounts[browser] = useragent.HeaderRange{Min: min, Max: max}
+
+		case "version_floor":
+			var browser, versionStr string
+			if !d.AllArgs(&browser, &versionStr) {
+				return d.ArgErr()
+			}
+			version, err := strconv.Atoi(versionStr)
+			if err != nil {
+				return d.Errf("invalid version %q: %v", versionStr, err)
+			}
+			if h.VersionFloors == nil {
+				h.VersionFloors = make(useragent.VersionFloors)
+			}
+			h.VersionFloors[browser] = version
+
+		case "release_file":
+			if !d.AllArgs(&h.ReleaseFile) {
+				return d.ArgErr()
+			}
+
+		case "release_floor":
+			var unit, nStr string
+			if !d.AllArgs(&unit, &nStr) {
+				return d.ArgErr()
+			}
+			n, err := strconv.Atoi(nStr)
+			if err != nil {
+				return d.Errf("invalid release_floor %q: %v", nStr, err)
+			}
+			if h.ReleaseFloor == nil {
+				h.ReleaseFloor = new(ReleaseFloor)
+			}
+			switch unit {
+			case "releases":
+				h.ReleaseFloor.Releases = n
+			case "days":
+				h.ReleaseFloor.Days = n
+			default:
+				return d.Errf("unknown release_floor unit %q, want releases or days", unit)
+			}
+
+		case "future_releases":
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			n, err := strconv.Atoi(d.Val())
+			if err != nil {
+				return d.Errf("invalid future_releases %q: %v", d.Val(), err)
+			}
+			h.FutureReleases = &n
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+
+		case "accept":
+			var key, value string
+			if !d.AllArgs(&key, &value) {
+				return d.ArgErr()
+			}
+			if h.AcceptHeaders == nil {
+				h.AcceptHeaders = make(map[string]string)
+			}
+			h.AcceptHeaders[key] = value
+
+		case "device_memory":
+			values := d.RemainingArgs()
+			if len(values) == 0 {
+				return d.ArgErr()
+			}
+			h.DeviceMemory = append(h.DeviceMemory, values...)
+
+		case "response_header":
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			if d.Val() == "off" {
+				h.DisableResponseHeader = true
+			} else {
+				h.ResponseHeader = d.Val()
+			}
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+
+		case "tor_policy":
+			args := d.RemainingArgs()
+			switch {
+			case len(args) == 1:
+			case len(args) == 2 && args[1] == "esr":
+				h.TorBlockESR = true
+			default:
+				return d.ArgErr()
+			}
+			h.TorPolicy = args[0]
+
+		case "in_app_policy":
+			if !d.AllArgs(&h.InAppPolicy) {
+				return d.ArgErr()
+			}
+
+		case "in_app_packages":
+			packages := d.RemainingArgs()
+			if len(packages) == 0 {
+				return d.ArgErr()
+			}
+			h.InAppPackages = append(h.InAppPackages, packages...)
+
+		case "upstream_header":
+			u, err := unmarshalUpstreamHeader(d)
+			if err != nil {
+				return err
+			}
+			h.Upstream = u
+
+		default:
+			return d.Errf("unrecognized subdirective %q", d.Val())
+		}
+	}
+	return nil
+}
+
+// checkConfig returns the config of check id, creating it when needed.
+func (h *HeaderChecker) checkConfig(id string) *CheckConfig {
+	if h.Checks == nil {
+		h.Checks = make(map[string]*CheckConfig)
+	}
+	c, ok := h.Checks[id]
+	if !ok || c == nil {
+		c = new(CheckConfig)
+		h.Checks[id] = c
+	}
+	return c
+}
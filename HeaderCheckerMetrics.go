@@ -0,0 +1,55 @@
+package CaddyHeaderVerification
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Finding modes used as metric label.
+const (
+	modeEnforce    = "enforce"
+	modeReportOnly = "report_only"
+)
+
+var headerCheckerMetrics = struct {
+	once     sync.Once
+	findings *prometheus.CounterVec
+}{}
+
+// initMetrics creates the metrics once and registers them with registry.
+func initMetrics(registry *prometheus.Registry) error {
+	const ns, sub = "caddy", "headerchecker"
+
+	headerCheckerMetrics.once.Do(func() {
+		headerCheckerMetrics.findings = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Subsystem: sub,
+			Name:      "findings_total",
+			Help:      "Number of failed header checks, by check, reason code and mode.",
+		}, []string{"check", "reason", "mode"})
+	})
+
+	// Several sites can use the handler, so the counter may already be registered.
+	err := registry.Register(headerCheckerMetrics.findings)
+	if err != nil && !errors.Is(err, prometheus.AlreadyRegisteredError{
+		ExistingCollector: headerCheckerMetrics.findings,
+		NewCollector:      headerCheckerMetrics.findings,
+	}) {
+		return err
+	}
+	return nil
+}
+
+// countFinding increments the findings counter when metrics are set up.
+func countFinding(f Finding, reportOnly bool) {
+	if headerCheckerMetrics.findings == nil {
+		return
+	}
+	mode := modeEnforce
+	if reportOnly {
+		mode = modeReportOnly
+	}
+	headerCheckerMetrics.findings.WithLabelValues(f.Check, f.Reason, mode).Inc()
+}
@@ -0,0 +1,55 @@
+package CaddyHeaderVerification
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// Request variables set by the handler. Each one is also available as the
+// placeholder {http.headerchecker.<name>} and as {http.vars.headerchecker.<name>}.
+const (
+	VarClass       = "headerchecker.class"
+	VarScore       = "headerchecker.score"
+	VarShadowScore = "headerchecker.shadow_score"
+	VarBrowser     = "headerchecker.browser"
+	VarReasons     = "headerchecker.reasons"
+	VarChecks      = "headerchecker.checks"
+	VarSecure      = "headerchecker.secure"
+
+	// VarVerdict holds the complete Verdict for other modules in this package.
+	VarVerdict = "headerchecker.verdict"
+)
+
+// publishVerdict makes v available as request vars and replacer placeholders.
+func publishVerdict(r *http.Request, v Verdict) {
+	values := map[string]any{
+		VarClass:       string(v.Class),
+		VarScore:       v.Score,
+		VarShadowScore: v.ShadowScore,
+		VarBrowser:     string(v.Browser),
+		VarReasons:     strings.Join(v.Reasons(), ","),
+		VarChecks:      strings.Join(v.FailedChecks(), ","),
+		VarSecure:      strconv.FormatBool(v.Class == ClassHuman),
+	}
+
+	ctx := r.Context()
+	caddyhttp.SetVar(ctx, VarVerdict, v)
+	repl, _ := ctx.Value(caddy.ReplacerCtxKey).(*caddy.Replacer)
+	for key, value := range values {
+		caddyhttp.SetVar(ctx, key, value)
+		if repl != nil {
+			repl.Set("http."+key, value)
+		}
+	}
+}
+
+// VerdictFromRequest returns the verdict a headerchecker handler stored on r
+// earlier in the route.
+func VerdictFromRequest(r *http.Request) (Verdict, bool) {
+	v, ok := caddyhttp.GetVar(r.Context(), VarVerdict).(Verdict)
+	return v, ok
+}
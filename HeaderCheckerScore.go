@@ -0,0 +1,112 @@
+package CaddyHeaderVerification
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// VerdictClass is the outcome of scoring a request.
+type VerdictClass string
+
+const (
+	ClassHuman      VerdictClass = "human"
+	ClassSuspicious VerdictClass = "suspicious"
+	ClassBot        VerdictClass = "bot"
+)
+
+// Default score thresholds. A score at or above a threshold puts the request in that class.
+const (
+	DefaultSuspiciousThreshold = 30
+	DefaultBotThreshold        = 60
+)
+
+// MaxScore is the highest score a request can get.
+const MaxScore = 100
+
+// defaultWeights is the score a failing check adds when no weight is configured.
+// Checks that used to only log a warning (sec_fetch, accept_language and
+// devtools_path) weigh less than the suspicious threshold together, so they
+// cannot turn a request that used to pass into a suspicious one.
+// unreleased_version depends on release data that ages, so on its own it
+// stays below the suspicious threshold as well.
+var defaultWeights = map[string]int{
+	CheckSecFetch:               10,
+	CheckAcceptLanguage:         10,
+	CheckDevtoolsPath:           5,
+	CheckHeaderCount:            40,
+	CheckOldBrowser:             40,
+	CheckAcceptCharset:          60,
+	CheckUAReduction:            40,
+	CheckFirefoxAccept:          40,
+	CheckDeviceMemory:           25,
+	CheckWindowsPlatformVersion: 40,
+	CheckClientHintVersions:     60,
+	CheckChromeAccept:           40,
+	CheckSecChUaBrand:           100,
+	CheckLinuxPlatform:          40,
+	CheckAcceptWildcard:         60,
+	CheckAcceptEncoding:         15,
+	CheckRequiredHeaders:        20,
+	CheckSafariAccept:           40,
+	CheckUAGrammar:              40,
+	CheckMobileHints:            40,
+	CheckAppPackage:             30,
+	CheckUnreleasedVersion:      25,
+	CheckClientHintSyntax:       60,
+	CheckGreaseBrand:            60,
+	CheckPlatformVersion:        40,
+	CheckPlatformToken:          40,
+}
+
+// weight returns the score check id adds when it fails.
+func (h HeaderChecker) weight(id string) int {
+	if c, ok := h.Checks[id]; ok && c != nil && c.Weight != nil {
+		return *c.Weight
+	}
+	switch id {
+	case CheckTorBrowser:
+		return h.policyWeight(h.torPolicy())
+	case CheckInAppBrowser:
+		return h.policyWeight(h.inAppPolicy())
+	}
+	return defaultWeights[id]
+}
+
+// suspiciousThreshold returns the score from which a request is suspicious.
+func (h HeaderChecker) suspiciousThreshold() int {
+	if h.SuspiciousThreshold != nil {
+		return *h.SuspiciousThreshold
+	}
+	return DefaultSuspiciousThreshold
+}
+
+// botThreshold returns the score from which a request is a bot.
+func (h HeaderChecker) botThreshold() int {
+	if h.BotThreshold != nil {
+		return *h.BotThreshold
+	}
+	return DefaultBotThreshold
+}
+
+// classify maps a score to a VerdictClass.
+func (h HeaderChecker) classify(score int) VerdictClass {
+	switch {
+	case score >= h.botThreshold():
+		return ClassBot
+	case score >= h.suspiciousThreshold():
+		return ClassSuspicious
+	default:
+		return ClassHuman
+	}
+}
+
+// setResponseHeaders writes the verdict, score and class response headers.
+func (h HeaderChecker) setResponseHeaders(header http.Header, verdict Verdict) {
+	name := h.responseHeader()
+	if name == "" {
+		return
+	}
+	header.Set(name, strconv.FormatBool(verdict.Class == ClassHuman))
+	header.Set(name+"-Score", strconv.Itoa(verdict.Score))
+	header.Set(name+"-Class", string(verdict.Class))
+}
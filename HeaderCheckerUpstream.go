@@ -0,0 +1,142 @@
+package CaddyHeaderVerification
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+
+	verdictheader "github.com/IgnifexLabs/CaddyHeaderVerification/VerdictHeader"
+)
+
+// MinUpstreamKeyLength is the shortest accepted HMAC key, in bytes.
+const MinUpstreamKeyLength = 32
+
+// UpstreamHeader configures the signed verdict header added to the request
+// before it is passed to the next handler, e.g. reverse_proxy. Services behind
+// Caddy verify it with the VerdictHeader package.
+type UpstreamHeader struct {
+	// Header is the name of the request header. Default: X-Headerchecker-Verdict.
+	Header string `json:"header,omitempty"`
+
+	// KeyID identifies the key, so keys can be rotated.
+	KeyID string `json:"key_id"`
+
+	// Key is the HMAC-SHA256 key, at least 32 bytes. Placeholders such as
+	// {env.HEADERCHECKER_KEY} are replaced at provision time.
+	Key string `json:"key"`
+
+	// Strip lists further request headers to remove before the request is passed on.
+	Strip []string `json:"strip,omitempty"`
+
+	key []byte
+}
+
+// header returns the name of the signed request header.
+func (u *UpstreamHeader) header() string {
+	if u != nil && u.Header != "" {
+		return u.Header
+	}
+	return verdictheader.DefaultHeader
+}
+
+// provision resolves placeholders in the key.
+func (u *UpstreamHeader) provision() {
+	u.key = []byte(caddy.NewReplacer().ReplaceKnown(u.Key, ""))
+}
+
+// secret returns the resolved key, or the configured one before provisioning.
+func (u *UpstreamHeader) secret() []byte {
+	if u.key != nil {
+		return u.key
+	}
+	return []byte(u.Key)
+}
+
+func (u *UpstreamHeader) validate() error {
+	if u.KeyID == "" {
+		return fmt.Errorf("upstream_header: key_id is required")
+	}
+	if strings.ContainsAny(u.KeyID, ";=, ") {
+		return fmt.Errorf("upstream_header: key_id %q must not contain ';', '=', ',' or spaces", u.KeyID)
+	}
+	if u.Key == "" {
+		return fmt.Errorf("upstream_header: key is required")
+	}
+	if len(u.secret()) < MinUpstreamKeyLength {
+		return fmt.Errorf("upstream_header: key must be at least %d bytes", MinUpstreamKeyLength)
+	}
+	return nil
+}
+
+// stripVerdictHeaders removes verdict headers sent by the client, so the next
+// handler cannot mistake them for ones set by the handler.
+func (h HeaderChecker) stripVerdictHeaders(r *http.Request) {
+	r.Header.Del(h.Upstream.header())
+	if name := h.responseHeader(); name != "" {
+		r.Header.Del(name)
+		r.Header.Del(name + "-Score")
+		r.Header.Del(name + "-Class")
+	}
+	if h.Upstream != nil {
+		for _, name := range h.Upstream.Strip {
+			r.Header.Del(name)
+		}
+	}
+}
+
+// signVerdict adds the signed verdict header to r when it is configured.
+func (h HeaderChecker) signVerdict(r *http.Request, v Verdict) {
+	if h.Upstream == nil {
+		return
+	}
+	r.Header.Set(h.Upstream.header(), verdictheader.Sign(verdictheader.Verdict{
+		KeyID:     h.Upstream.KeyID,
+		Timestamp: h.clock(),
+		Score:     v.Score,
+		Class:     string(v.Class),
+		Reasons:   v.Reasons(),
+	}, h.Upstream.secret()))
+}
+
+// unmarshalUpstreamHeader parses the upstream_header block. Syntax:
+//
+//	upstream_header {
+//	    header <name>
+//	    key_id <id>
+//	    key <secret>
+//	    strip <header...>
+//	}
+func unmarshalUpstreamHeader(d *caddyfile.Dispenser) (*UpstreamHeader, error) {
+	if d.NextArg() {
+		return nil, d.ArgErr()
+	}
+	u := new(UpstreamHeader)
+	for nesting := d.Nesting(); d.NextBlock(nesting); {
+		switch d.Val() {
+		case "header":
+			if !d.AllArgs(&u.Header) {
+				return nil, d.ArgErr()
+			}
+		case "key_id":
+			if !d.AllArgs(&u.KeyID) {
+				return nil, d.ArgErr()
+			}
+		case "key":
+			if !d.AllArgs(&u.Key) {
+				return nil, d.ArgErr()
+			}
+		case "strip":
+			names := d.RemainingArgs()
+			if len(names) == 0 {
+				return nil, d.ArgErr()
+			}
+			u.Strip = append(u.Strip, names...)
+		default:
+			return nil, d.Errf("unrecognized upstream_header subdirective %q", d.Val())
+		}
+	}
+	return u, nil
+}
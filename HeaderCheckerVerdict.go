@@ -0,0 +1,205 @@
+package CaddyHeaderVerification
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+)
+
+// Severity says how strong a signal a finding is on its own.
+type Severity string
+
+const (
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// Reason codes. They are stable and can be used in logs, metrics and routing.
+const (
+	ReasonSecFetchMissing           = "sec_fetch_missing"
+	ReasonSecFetchMismatch          = "sec_fetch_mismatch"
+	ReasonAcceptLanguageMissing     = "accept_language_missing"
+	ReasonAcceptLanguageWhitespace  = "accept_language_whitespace"
+	ReasonDevtoolsPath              = "devtools_path_requested"
+	ReasonHeaderCountTooLow         = "header_count_too_low"
+	ReasonHeaderCountTooHigh        = "header_count_too_high"
+	ReasonBrowserTooOld             = "browser_too_old"
+	ReasonBrowserUnreleased         = "browser_version_unreleased"
+	ReasonAcceptCharsetPresent      = "accept_charset_present"
+	ReasonUANotReduced              = "ua_not_reduced"
+	ReasonAcceptVersionUnsupported  = "accept_version_unsupported"
+	ReasonAcceptMismatch            = "accept_mismatch"
+	ReasonAcceptImageMismatch       = "accept_image_mismatch"
+	ReasonDeviceMemoryMissing       = "device_memory_missing"
+	ReasonDeviceMemoryUnexpected    = "device_memory_unexpected"
+	ReasonWindowsPlatformVersion    = "windows_platform_version_invalid"
+	ReasonPlatformVersion           = "platform_version_invalid"
+	ReasonClientHintVersionMismatch = "client_hint_version_mismatch"
+	ReasonClientHintBrandMismatch   = "client_hint_brand_mismatch"
+	ReasonClientHintBuildMismatch   = "client_hint_build_mismatch"
+	ReasonClientHintMalformed       = "client_hint_malformed"
+	ReasonGreaseBrandMismatch       = "grease_brand_mismatch"
+	ReasonSecChUaUnknownBrand       = "sec_ch_ua_unknown_brand"
+	ReasonLinuxPlatformToken        = "linux_platform_token_mismatch"
+	ReasonPlatformTokenMismatch     = "platform_token_mismatch"
+	ReasonAcceptWildcard            = "accept_wildcard_only"
+	ReasonAcceptEncodingMismatch    = "accept_encoding_mismatch"
+	ReasonRequiredHeaderMissing     = "required_header_missing"
+	ReasonUnexpectedHeader          = "unexpected_header"
+	ReasonHeaderValueMismatch       = "header_value_mismatch"
+	ReasonForbiddenHeader           = "forbidden_header_present"
+	ReasonUAGrammarMismatch         = "ua_grammar_mismatch"
+	ReasonMobileHintMismatch        = "mobile_hint_mismatch"
+	ReasonPlatformHintMismatch      = "platform_hint_mismatch"
+	ReasonModelHintMismatch         = "model_hint_mismatch"
+	ReasonTorBrowser                = "tor_browser_detected"
+	ReasonInAppBrowser              = "in_app_browser_detected"
+	ReasonAppPackageNotAllowed      = "app_package_not_allowed"
+	ReasonAppPackageUnexpected      = "app_package_unexpected"
+)
+
+// Finding is a failed check. Checks return nil when the request passes.
+type Finding struct {
+	// Check is the ID of the check that failed.
+	Check string `json:"check"`
+
+	// Reason is a stable code for why the check failed.
+	Reason string `json:"reason"`
+
+	Severity Severity `json:"severity"`
+
+	// Expected and Observed are the evidence, e.g. the expected and received Accept value.
+	Expected string `json:"expected,omitempty"`
+	Observed string `json:"observed,omitempty"`
+}
+
+// Verdict aggregates the findings of all checks for one request.
+type Verdict struct {
+	// Browser is the browser detected from the request headers.
+	Browser useragent.BrowserKind `json:"browser"`
+
+	Score    int          `json:"score"`
+	Class    VerdictClass `json:"class"`
+	Findings []Finding    `json:"findings,omitempty"`
+
+	// Reported holds the findings of report-only checks. They do not count
+	// towards Score and Class.
+	Reported []Finding `json:"reported,omitempty"`
+
+	// ShadowScore is the score the request would get if no check were report-only.
+	ShadowScore int `json:"shadow_score"`
+}
+
+// add records f and adds the weight of its check, capped at MaxScore.
+// Findings of report-only checks only add to ShadowScore.
+func (v *Verdict) add(h HeaderChecker, f Finding) {
+	weight := h.weight(f.Check)
+	v.ShadowScore = min(v.ShadowScore+weight, MaxScore)
+	if h.reportOnly(f.Check) {
+		v.Reported = append(v.Reported, f)
+		return
+	}
+	v.Findings = append(v.Findings, f)
+	v.Score = min(v.Score+weight, MaxScore)
+}
+
+// Reasons returns the reason codes of all findings.
+func (v Verdict) Reasons() []string {
+	reasons := make([]string, 0, len(v.Findings))
+	for _, f := range v.Findings {
+		reasons = append(reasons, f.Reason)
+	}
+	return reasons
+}
+
+// FailedChecks returns the IDs of all checks that produced a finding.
+func (v Verdict) FailedChecks() []string {
+	ids := make([]string, 0, len(v.Findings))
+	for _, f := range v.Findings {
+		ids = append(ids, f.Check)
+	}
+	return ids
+}
+
+// checkFunc runs one check and returns a finding when the request fails it.
+type checkFunc func(h HeaderChecker, r *http.Request) *Finding
+
+// allChecks lists every check in the order Evaluate runs them.
+var allChecks = []struct {
+	id  string
+	run checkFunc
+}{
+	{CheckSecFetch, HeaderChecker.checkSecFetch},
+	{CheckAcceptLanguage, HeaderChecker.checkAcceptLanguage},
+	{CheckDevtoolsPath, HeaderChecker.checkDevtoolsPath},
+	{CheckHeaderCount, HeaderChecker.checkHeaderCount},
+	{CheckOldBrowser, HeaderChecker.checkOldBrowser},
+	{CheckUnreleasedVersion, HeaderChecker.checkUnreleasedVersion},
+	{CheckAcceptCharset, HeaderChecker.checkAcceptCharset},
+	{CheckUAReduction, HeaderChecker.checkUAReduction},
+	{CheckFirefoxAccept, HeaderChecker.checkFirefoxAccept},
+	{CheckDeviceMemory, HeaderChecker.checkDeviceMemory},
+	{CheckClientHintSyntax, HeaderChecker.checkClientHintSyntax},
+	{CheckWindowsPlatformVersion, HeaderChecker.checkWindowsPlatformVersion},
+	{CheckPlatformVersion, HeaderChecker.checkPlatformVersion},
+	{CheckClientHintVersions, HeaderChecker.checkClientHintVersions},
+	{CheckChromeAccept, HeaderChecker.checkChromeAccept},
+	{CheckSecChUaBrand, HeaderChecker.checkSecChUaBrand},
+	{CheckGreaseBrand, HeaderChecker.checkGreaseBrand},
+	{CheckLinuxPlatform, HeaderChecker.checkLinuxPlatform},
+	{CheckPlatformToken, HeaderChecker.checkPlatformToken},
+	{CheckAcceptWildcard, HeaderChecker.checkAcceptWildcard},
+	{CheckAcceptEncoding, HeaderChecker.checkAcceptEncoding},
+	{CheckRequiredHeaders, HeaderChecker.checkRequiredHeaders},
+	{CheckSafariAccept, HeaderChecker.checkSafariAccept},
+	{CheckUAGrammar, HeaderChecker.checkUAGrammar},
+	{CheckMobileHints, HeaderChecker.checkMobileHints},
+	{CheckTorBrowser, HeaderChecker.checkTorBrowser},
+	{CheckInAppBrowser, HeaderChecker.checkInAppBrowser},
+	{CheckAppPackage, HeaderChecker.checkAppPackage},
+}
+
+func isCheckID(id string) bool {
+	for _, c := range allChecks {
+		if c.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate runs every enabled check against r and scores the findings.
+func (h HeaderChecker) Evaluate(r *http.Request) Verdict {
+	v := Verdict{Browser: h.profile().DetectBrowser(r.Header)}
+	for _, c := range allChecks {
+		if !h.enabled(c.id) {
+			continue
+		}
+		f := c.run(h, r)
+		if f == nil {
+			continue
+		}
+		h.logFinding(*f)
+		countFinding(*f, h.reportOnly(c.id))
+		v.add(h, *f)
+	}
+	v.Class = h.classify(v.Score)
+	return v
+}
+
+func (h HeaderChecker) logFinding(f Finding) {
+	if h.logger == nil {
+		return
+	}
+	h.logger.Warn("check failed",
+		zap.String("check", f.Check),
+		zap.Bool("report_only", h.reportOnly(f.Check)),
+		zap.String("reason", f.Reason),
+		zap.String("severity", string(f.Severity)),
+		zap.String("expected", f.Expected),
+		zap.String("observed", f.Observed),
+	)
+}
@@ -0,0 +1,99 @@
+package CaddyHeaderVerification
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/parser"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+var _ caddyhttp.CELLibraryProducer = (*MatchHeaderVerdict)(nil)
+
+// celVerdictFuncs are the functions available in expression matchers. Each one
+// reads the verdict a headerchecker handler stored on the request. Without
+// one they see an empty verdict: they have no checker config to evaluate the
+// request with, and the defaults could class it differently than the handler.
+var celVerdictFuncs = []struct {
+	name       string
+	resultType *cel.Type
+	value      func(v Verdict) ref.Val
+}{
+	{"bot_score", cel.IntType, func(v Verdict) ref.Val {
+		return types.Int(v.Score)
+	}},
+	{"bot_class", cel.StringType, func(v Verdict) ref.Val {
+		return types.String(v.Class)
+	}},
+	{"bot_browser", cel.StringType, func(v Verdict) ref.Val {
+		return types.String(v.Browser)
+	}},
+	{"bot_reasons", cel.ListType(cel.StringType), func(v Verdict) ref.Val {
+		return types.NewStringList(types.DefaultTypeAdapter, v.Reasons())
+	}},
+	{"bot_checks", cel.ListType(cel.StringType), func(v Verdict) ref.Val {
+		return types.NewStringList(types.DefaultTypeAdapter, v.FailedChecks())
+	}},
+}
+
+// CELLibrary makes the verdict available in CEL expression matchers.
+//
+// Example:
+//
+//	expression bot_score() > 60 && 'sec_ch_ua_unknown_brand' in bot_reasons()
+func (MatchHeaderVerdict) CELLibrary(_ caddy.Context) (cel.Library, error) {
+	requestType := cel.ObjectType("http.Request")
+
+	var envOptions []cel.EnvOption
+	for _, f := range celVerdictFuncs {
+		funcName := "headerchecker_" + f.name
+		value := f.value
+		envOptions = append(envOptions,
+			cel.Macros(parser.NewGlobalMacro(f.name, 0, celRequestMacroExpander(funcName))),
+			cel.Function(funcName,
+				cel.Overload(funcName+"_request", []*cel.Type{requestType}, f.resultType,
+					cel.UnaryBinding(func(arg ref.Val) ref.Val {
+						r, err := celRequest(arg)
+						if err != nil {
+							return types.WrapErr(err)
+						}
+						v, _ := VerdictFromRequest(r)
+						return value(v)
+					}),
+				),
+			),
+		)
+	}
+	return caddyhttp.NewMatcherCELLibrary(envOptions, nil), nil
+}
+
+// celRequestMacroExpander expands a call without arguments to <funcName>(req).
+func celRequestMacroExpander(funcName string) parser.MacroExpander {
+	return func(eh cel.MacroExprFactory, target ast.Expr, args []ast.Expr) (ast.Expr, *common.Error) {
+		if len(args) != 0 {
+			return nil, eh.NewError(0, "function takes no arguments")
+		}
+		return eh.NewCall(funcName, eh.NewIdent(caddyhttp.CELRequestVarName)), nil
+	}
+}
+
+// celRequest returns the *http.Request behind the CEL req variable.
+func celRequest(arg ref.Val) (*http.Request, error) {
+	native, err := arg.ConvertToNative(reflect.TypeOf((*http.Request)(nil)))
+	if err != nil {
+		return nil, err
+	}
+	r, ok := native.(*http.Request)
+	if !ok {
+		return nil, fmt.Errorf("headerchecker: expected *http.Request, got %T", native)
+	}
+	return r, nil
+}
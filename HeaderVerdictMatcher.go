@@ -0,0 +1,163 @@
+package CaddyHeaderVerification
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// Interface guards
+var (
+	_ caddy.Provisioner                 = (*MatchHeaderVerdict)(nil)
+	_ caddy.Validator                   = (*MatchHeaderVerdict)(nil)
+	_ caddyfile.Unmarshaler             = (*MatchHeaderVerdict)(nil)
+	_ caddyhttp.RequestMatcherWithError = (*MatchHeaderVerdict)(nil)
+)
+
+func init() {
+	caddy.RegisterModule(MatchHeaderVerdict{})
+}
+
+// MatchHeaderVerdict matches requests by their headerchecker verdict. It uses
+// the verdict of a headerchecker handler that already ran for the request, and
+// otherwise evaluates the request itself.
+type MatchHeaderVerdict struct {
+	// Classes the verdict must be one of: human, suspicious or bot.
+	Classes []string `json:"classes,omitempty"`
+
+	// MinScore is the lowest score that matches.
+	MinScore *int `json:"min_score,omitempty"`
+
+	// Checker is the configuration used when the request has no verdict yet.
+	// Default: the headerchecker defaults.
+	Checker *HeaderChecker `json:"checker,omitempty"`
+}
+
+// CaddyModule returns the Caddy module information.
+func (MatchHeaderVerdict) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.matchers.headerverdict",
+		New: func() caddy.Module { return new(MatchHeaderVerdict) },
+	}
+}
+
+// Provision sets up the checker used to evaluate requests.
+func (m *MatchHeaderVerdict) Provision(ctx caddy.Context) error {
+	if m.Checker == nil {
+		m.Checker = new(HeaderChecker)
+	}
+	return m.Checker.Provision(ctx)
+}
+
+// Validate checks that the matcher can match anything.
+func (m *MatchHeaderVerdict) Validate() error {
+	if len(m.Classes) == 0 && m.MinScore == nil {
+		return fmt.Errorf("headerverdict: a class or min_score is required")
+	}
+	for _, class := range m.Classes {
+		switch VerdictClass(class) {
+		case ClassHuman, ClassSuspicious, ClassBot:
+		default:
+			return fmt.Errorf("headerverdict: unknown class %q", class)
+		}
+	}
+	if m.MinScore != nil && (*m.MinScore < 0 || *m.MinScore > MaxScore) {
+		return fmt.Errorf("headerverdict: min_score %d is outside 0-%d", *m.MinScore, MaxScore)
+	}
+	if m.Checker != nil {
+		return m.Checker.Validate()
+	}
+	return nil
+}
+
+// verdict returns the verdict stored on r, or evaluates and stores one.
+func (m MatchHeaderVerdict) verdict(r *http.Request) Verdict {
+	if v, ok := VerdictFromRequest(r); ok {
+		return v
+	}
+	var checker HeaderChecker
+	if m.Checker != nil {
+		checker = *m.Checker
+	}
+	v := checker.Evaluate(r)
+	publishVerdict(r, v)
+	return v
+}
+
+// Match returns true if r matches m.
+func (m MatchHeaderVerdict) Match(r *http.Request) bool {
+	match, _ := m.MatchWithError(r)
+	return match
+}
+
+// MatchWithError returns true if r matches m.
+func (m MatchHeaderVerdict) MatchWithError(r *http.Request) (bool, error) {
+	v := m.verdict(r)
+	if m.MinScore != nil && v.Score < *m.MinScore {
+		return false, nil
+	}
+	if len(m.Classes) == 0 {
+		return true, nil
+	}
+	for _, class := range m.Classes {
+		if VerdictClass(class) == v.Class {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UnmarshalCaddyfile sets up the matcher from Caddyfile tokens. Syntax:
+//
+//	headerverdict [<class...>] {
+//	    class <class...>
+//	    min_score <score>
+//	    checker {
+//	        <headerchecker subdirectives>
+//	    }
+//	}
+func (m *MatchHeaderVerdict) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	// iterate to merge multiple matchers into one
+	for d.Next() {
+		m.Classes = append(m.Classes, d.RemainingArgs()...)
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			switch d.Val() {
+			case "class":
+				classes := d.RemainingArgs()
+				if len(classes) == 0 {
+					return d.ArgErr()
+				}
+				m.Classes = append(m.Classes, classes...)
+
+			case "min_score":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				score, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid min_score %q: %v", d.Val(), err)
+				}
+				m.MinScore = &score
+				if d.NextArg() {
+					return d.ArgErr()
+				}
+
+			case "checker":
+				checker := new(HeaderChecker)
+				if err := checker.UnmarshalCaddyfile(d.NewFromNextSegment()); err != nil {
+					return err
+				}
+				m.Checker = checker
+
+			default:
+				return d.Errf("unrecognized headerverdict subdirective %q", d.Val())
+			}
+		}
+	}
+	return nil
+}
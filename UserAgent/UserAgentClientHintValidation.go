@@ -1,28 +1,36 @@
 package useragent
 
-import "strings"
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
 
-var allowedBrands = []string{
-	"Google Chrome",
-	"Microsoft Edge",
-	"Brave",
+// IsBotFromSecChUa returns true if the Sec-Ch-Ua header
+// does NOT contain any of the brands of the default profiles.
+func IsBotFromSecChUa(secChUa string) bool {
+	return DefaultProfiles().IsBotFromSecChUa(secChUa)
 }
 
 // IsBotFromSecChUa returns true if the Sec-Ch-Ua header
-// does NOT contain any of the allowed brands.
-func IsBotFromSecChUa(secChUa string) bool {
+// does NOT contain any of the brands in p.
+func (p *Profiles) IsBotFromSecChUa(secChUa string) bool {
 
 	// Examples of bad headers header:
 	//  77 Sec-Ch-Ua":["\"Not;A=Brand\";v=\"24\", \"Chromium\";v=\"128\""] Only chromium
 	// 39 Sec-Ch-Ua":[""] en empty sec-ch-ua?
 	// 27 Sec-Ch-Ua":["\"Not)A;Brand\";v=\"8\", \"Chromium\";v=\"138\", \"HeadlessChrome\";v=\"138\""] HeadlessChrome
-	lower := strings.ToLower(secChUa)
-	if secChUa == "" {
-		// No header at all: treat as bot (up to you)
+	list, err := ParseBrandList(secChUa)
+	if err != nil || len(list) == 0 {
+		// No header at all, or one no browser sends: treat as bot
 		return true
 	}
-	for _, brand := range allowedBrands {
-		if strings.Contains(lower, strings.ToLower(brand)) {
+	for _, brand := range p.Brands {
+		if list.Has(brand) {
 			// This looks like a mainstream browser
 			return false
 		}
@@ -31,3 +39,280 @@ func IsBotFromSecChUa(secChUa string) bool {
 	// No allowed brand found => treat as bot
 	return true
 }
+
+// Brands of Chromium browsers in Sec-CH-UA.
+const (
+	BrandChromium       = "Chromium"
+	BrandChrome         = "Google Chrome"
+	BrandEdge           = "Microsoft Edge"
+	BrandEdgeWebView2   = "Microsoft Edge WebView2"
+	BrandBrave          = "Brave"
+	BrandOpera          = "Opera"
+	BrandOperaGX        = "Opera GX"
+	BrandSamsung        = "Samsung Internet"
+	BrandYandex         = "YaBrowser"
+	BrandAndroidWebView = "Android WebView"
+)
+
+// BrandVersion is one entry of Sec-CH-UA or Sec-CH-UA-Full-Version-List.
+type BrandVersion struct {
+	Brand   string
+	Version string
+}
+
+// BrandList is a parsed Sec-CH-UA or Sec-CH-UA-Full-Version-List header.
+type BrandList []BrandVersion
+
+// ParseBrandList parses a Sec-CH-UA or Sec-CH-UA-Full-Version-List header:
+// an sf-list of brands, each an sf-string with the version in an sf-string
+// v parameter.
+func ParseBrandList(header string) (BrandList, error) {
+	items, err := ParseList(header)
+	if err != nil {
+		return nil, err
+	}
+	list := make(BrandList, 0, len(items))
+	for i, item := range items {
+		brand, ok := item.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("brand %d is not a string", i+1)
+		}
+		v, _ := item.Params.Get("v")
+		version, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("brand %q has no string version", brand)
+		}
+		list = append(list, BrandVersion{Brand: brand, Version: version})
+	}
+	return list, nil
+}
+
+// Brands returns the brands in the Sec-CH-UA header of h. A malformed header
+// has no brands.
+func Brands(h http.Header) BrandList {
+	list, _ := ParseBrandList(h.Get("Sec-Ch-Ua"))
+	return list
+}
+
+// ParseHintString parses a client hint whose value is an sf-string, such as
+// Sec-CH-UA-Platform or Sec-CH-UA-Full-Version.
+func ParseHintString(header string) (string, error) {
+	item, err := ParseItem(header)
+	if err != nil {
+		return "", err
+	}
+	s, ok := item.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("value is not a string")
+	}
+	return s, nil
+}
+
+// ParseHintBoolean parses a client hint whose value is an sf-boolean, such as
+// Sec-CH-UA-Mobile.
+func ParseHintBoolean(header string) (bool, error) {
+	item, err := ParseItem(header)
+	if err != nil {
+		return false, err
+	}
+	b, ok := item.Value.(bool)
+	if !ok {
+		return false, fmt.Errorf("value is not a boolean")
+	}
+	return b, nil
+}
+
+// Structured field types of the UA client hints.
+const (
+	HintBrandList  = "sf-list of brands"
+	HintString     = "sf-string"
+	HintBoolean    = "sf-boolean"
+	HintStringList = "sf-list of sf-strings"
+)
+
+// clientHintTypes maps the UA client hints to their structured field type.
+var clientHintTypes = map[string]string{
+	"Sec-Ch-Ua":                   HintBrandList,
+	"Sec-Ch-Ua-Full-Version-List": HintBrandList,
+	"Sec-Ch-Ua-Arch":              HintString,
+	"Sec-Ch-Ua-Bitness":           HintString,
+	"Sec-Ch-Ua-Full-Version":      HintString,
+	"Sec-Ch-Ua-Model":             HintString,
+	"Sec-Ch-Ua-Platform":          HintString,
+	"Sec-Ch-Ua-Platform-Version":  HintString,
+	"Sec-Ch-Ua-Mobile":            HintBoolean,
+	"Sec-Ch-Ua-Wow64":             HintBoolean,
+	"Sec-Ch-Ua-Form-Factors":      HintStringList,
+}
+
+// ClientHintNames returns the UA client hints ValidateClientHint knows, in
+// canonical form and sorted.
+func ClientHintNames() []string {
+	return slices.Sorted(maps.Keys(clientHintTypes))
+}
+
+// ClientHintType returns the structured field type of the UA client hint name.
+func ClientHintType(name string) (string, bool) {
+	t, ok := clientHintTypes[http.CanonicalHeaderKey(name)]
+	return t, ok
+}
+
+// ValidateClientHint reports whether value is valid for the UA client hint
+// name. Headers that are no UA client hint are not checked.
+func ValidateClientHint(name, value string) error {
+	t, _ := ClientHintType(name)
+	var err error
+	switch t {
+	case HintBrandList:
+		_, err = ParseBrandList(value)
+	case HintString:
+		_, err = ParseHintString(value)
+	case HintBoolean:
+		_, err = ParseHintBoolean(value)
+	case HintStringList:
+		var items []Item
+		items, err = ParseList(value)
+		for i, item := range items {
+			if _, ok := item.Value.(string); !ok {
+				return fmt.Errorf("member %d is not a string", i+1)
+			}
+		}
+	}
+	return err
+}
+
+// Version returns the version of brand.
+func (l BrandList) Version(brand string) (string, bool) {
+	for _, b := range l {
+		if b.Brand == brand {
+			return b.Version, true
+		}
+	}
+	return "", false
+}
+
+// Has reports whether the list contains brand.
+func (l BrandList) Has(brand string) bool {
+	_, ok := l.Version(brand)
+	return ok
+}
+
+// reGreaseBrand matches the GREASE brands Chromium adds to Sec-CH-UA, such as
+// "Not(A:Brand" or, in older versions, " Not A;Brand".
+var reGreaseBrand = regexp.MustCompile(`^ ?Not.A.Brand$`)
+
+// IsGreaseBrand reports whether brand is a GREASE brand.
+func IsGreaseBrand(brand string) bool {
+	return reGreaseBrand.MatchString(brand)
+}
+
+// greaseChars are the characters Chromium puts into its GREASE brand.
+const greaseChars = " (:-./);=?_"
+
+// greaseVersions are the versions Chromium gives its GREASE brand.
+var greaseVersions = []string{"8", "99", "24"}
+
+// brandOrders are the positions of the GREASE brand, Chromium and the browser
+// brand in the brand list, picked by the major version modulo 6.
+var brandOrders = [6][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+
+// GreaseBrand returns the GREASE brand Chromium adds to the brand lists of
+// major, e.g. "Not(A:Brand" with version 8 for 144. full selects the version
+// of Sec-CH-UA-Full-Version-List, e.g. 8.0.0.0.
+func GreaseBrand(major int, full bool) BrandVersion {
+	n := len(greaseChars)
+	brand := "Not" + greaseChars[major%n:major%n+1] + "A" + greaseChars[(major+1)%n:(major+1)%n+1] + "Brand"
+	version := greaseVersions[major%len(greaseVersions)]
+	if full {
+		version += ".0.0.0"
+	}
+	return BrandVersion{Brand: brand, Version: version}
+}
+
+// GreasedBrandOrder returns the brand names in the order Chromium major sends
+// them: the GREASE brand, Chromium and brand, permuted by the major version.
+// Without a brand only the GREASE brand and Chromium are sent.
+func GreasedBrandOrder(major int, brand string) []string {
+	grease := GreaseBrand(major, false).Brand
+	if brand == "" {
+		order := make([]string, 2)
+		order[major%2] = grease
+		order[(major+1)%2] = BrandChromium
+		return order
+	}
+	positions := brandOrders[major%len(brandOrders)]
+	order := make([]string, 3)
+	order[positions[0]] = grease
+	order[positions[1]] = BrandChromium
+	order[positions[2]] = brand
+	return order
+}
+
+// browserBrand returns the first brand in the first three entries of l that
+// is neither a GREASE brand nor Chromium, or "".
+func (l BrandList) browserBrand() string {
+	for _, b := range l[:min(3, len(l))] {
+		if !IsGreaseBrand(b.Brand) && b.Brand != BrandChromium {
+			return b.Brand
+		}
+	}
+	return ""
+}
+
+// FollowsGrease reports whether l starts with the brands Chromium major
+// generates, in their order and with the GREASE version, full selecting the
+// versions of Sec-CH-UA-Full-Version-List. Brands that follow them, such as
+// "Microsoft Edge WebView2", are not checked.
+func (l BrandList) FollowsGrease(major int, full bool) bool {
+	order := GreasedBrandOrder(major, l.browserBrand())
+	if len(l) < len(order) {
+		return false
+	}
+	grease := GreaseBrand(major, full)
+	for i, brand := range order {
+		if l[i].Brand != brand || (brand == grease.Brand && l[i].Version != grease.Version) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectedGrease returns the start of the brand list Chromium major would
+// send for the browser brand in l, with the GREASE version and without the
+// versions of the other brands, for evidence.
+func (l BrandList) ExpectedGrease(major int, full bool) string {
+	grease := GreaseBrand(major, full)
+	var b strings.Builder
+	for i, brand := range GreasedBrandOrder(major, l.browserBrand()) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(brand))
+		if brand == grease.Brand {
+			b.WriteString(";v=" + strconv.Quote(grease.Version))
+		}
+	}
+	return b.String()
+}
+
+// OnlyBrands reports whether the list holds one GREASE brand and otherwise
+// nothing but brands.
+func (l BrandList) OnlyBrands(brands ...string) bool {
+	grease := 0
+	for _, b := range l {
+		switch {
+		case IsGreaseBrand(b.Brand):
+			grease++
+		case !slices.Contains(brands, b.Brand):
+			return false
+		}
+	}
+	return grease == 1
+}
+
+// Major returns the major version of brand, or "" when it is missing.
+func (l BrandList) Major(brand string) string {
+	v, _ := l.Version(brand)
+	major, _, _ := strings.Cut(v, ".")
+	return major
+}
@@ -10,16 +10,38 @@ import (
 type BrowserKind string
 
 const (
-	BrowserChrome  BrowserKind = "chrome"
-	BrowserEdge    BrowserKind = "edge"
-	BrowserFirefox BrowserKind = "firefox"
-	BrowserBrave   BrowserKind = "brave"
-	BrowserUnknown BrowserKind = "unknown"
+	BrowserChrome         BrowserKind = "chrome"
+	BrowserChromeAndroid  BrowserKind = "chrome_android" // Chrome on Android phones and tablets
+	BrowserEdge           BrowserKind = "edge"
+	BrowserEdgeWebView2   BrowserKind = "edge_webview2" // Edge WebView2 controls embedded in apps
+	BrowserEdgeAndroid    BrowserKind = "edge_android"  // Edge on Android phones and tablets (EdgA)
+	BrowserFirefox        BrowserKind = "firefox"
+	BrowserFirefoxAndroid BrowserKind = "firefox_android"
+	BrowserTor            BrowserKind = "tor" // Tor Browser and Mullvad Browser
+	BrowserBrave          BrowserKind = "brave"
+	BrowserOpera          BrowserKind = "opera" // Opera and Opera GX, desktop and Android
+	BrowserVivaldi        BrowserKind = "vivaldi"
+	BrowserSamsung        BrowserKind = "samsung" // Samsung Internet
+	BrowserYandex         BrowserKind = "yandex"  // Yandex Browser
+	BrowserSafari         BrowserKind = "safari"
+	BrowserChromeIOS      BrowserKind = "chrome_ios"  // Chrome on iPhone and iPad (CriOS)
+	BrowserFirefoxIOS     BrowserKind = "firefox_ios" // Firefox on iPhone and iPad (FxiOS)
+	BrowserEdgeIOS        BrowserKind = "edge_ios"    // Edge on iPhone and iPad (EdgiOS)
+	BrowserAndroidWebView BrowserKind = "android_webview"
+	BrowserInApp          BrowserKind = "in_app" // in-app browsers of social apps
+	BrowserUnknown        BrowserKind = "unknown"
 )
 
+// HeaderRange is the accepted number of request headers for a browser.
+type HeaderRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
 type HeaderCheckResult struct {
 	Browser    BrowserKind
 	HeaderLen  int
+	Min, Max   int
 	WithinSpec bool
 	Reason     string
 }
@@ -28,85 +50,242 @@ var (
 	reFirefox = regexp.MustCompile(`Firefox/\d+\.\d+`)
 	reChrome  = regexp.MustCompile(`Chrome/\d+\.\d+`)
 	reEdge    = regexp.MustCompile(`Edg/\d+\.\d+`)
+	reEdgeA   = regexp.MustCompile(`EdgA/\d+\.\d+`)
+	reSafari  = regexp.MustCompile(`Version/\d+(\.\d+)* (Mobile/\w+ )?Safari/\d+`)
 )
 
+// nonSafariTokens are UA tokens of other browsers that also carry "Safari/".
+var nonSafariTokens = []string{"Chrome/", "Chromium/", "CriOS/", "FxiOS/", "EdgiOS/", "Firefox/", "OPR/", "OPT/"}
+
+// IsAndroid reports whether ua carries an Android platform token.
+func IsAndroid(ua string) bool {
+	return strings.Contains(ua, "; Android ")
+}
+
+// IsEdge reports whether ua carries the Edg/ token of desktop Edge and WebView2.
+func IsEdge(ua string) bool {
+	return reEdge.MatchString(ua) || strings.Contains(strings.ToLower(ua), "edg/")
+}
+
+// IsEdgeAndroid reports whether ua carries the EdgA/ token of Edge on Android.
+func IsEdgeAndroid(ua string) bool {
+	return reEdgeA.MatchString(ua)
+}
+
+// chromiumDerivatives are the Chromium browsers recognized by their own UA
+// token. Opera, Samsung Internet and Yandex Browser report their own version
+// in the token and the Chromium version in Chrome/.
+var chromiumDerivatives = []struct {
+	token   string
+	browser BrowserKind
+}{
+	{"OPR/", BrowserOpera},
+	{"SamsungBrowser/", BrowserSamsung},
+	{"YaBrowser/", BrowserYandex},
+	{"Vivaldi/", BrowserVivaldi},
+}
+
+// ChromiumDerivative returns the Chromium browser whose token ua carries, or
+// BrowserUnknown. Vivaldi only has a token in some builds; without it, it
+// sends the same headers as Chrome.
+func ChromiumDerivative(ua string) BrowserKind {
+	if !reChrome.MatchString(ua) {
+		return BrowserUnknown
+	}
+	for _, d := range chromiumDerivatives {
+		if strings.Contains(ua, d.token) {
+			return d.browser
+		}
+	}
+	return BrowserUnknown
+}
+
+// iosBrowsers are the browsers on iPhone and iPad recognized by their UA
+// token. They are built on WebKit, so they send the headers of Safari.
+var iosBrowsers = []struct {
+	token   string
+	browser BrowserKind
+}{
+	{"CriOS/", BrowserChromeIOS},
+	{"FxiOS/", BrowserFirefoxIOS},
+	{"EdgiOS/", BrowserEdgeIOS},
+}
+
+// IOSBrowser returns the iPhone or iPad browser whose token ua carries, or
+// BrowserUnknown. The platform is left to the UA grammar of the profile, so a
+// token on another platform is still checked against it.
+func IOSBrowser(ua string) BrowserKind {
+	for _, b := range iosBrowsers {
+		if strings.Contains(ua, b.token) {
+			return b.browser
+		}
+	}
+	return BrowserUnknown
+}
+
+// IsBrave reports whether h is from Brave: a Chrome User-Agent, which Brave
+// sends unchanged, with the "Brave" brand in Sec-CH-UA. The brand only names
+// the browser; the brave profile decides whether the rest fits.
+func IsBrave(h http.Header) bool {
+	return reChrome.MatchString(h.Get("User-Agent")) && Brands(h).Has(BrandBrave)
+}
+
+// IsMobile reports whether ua carries the Mobile token Chromium adds on phones.
+func IsMobile(ua string) bool {
+	return strings.Contains(ua, " Mobile Safari/") || strings.Contains(ua, " Mobile/")
+}
+
+// IsSafari reports whether ua is a Safari User-Agent: "Version/x Safari/y"
+// without the token of another browser.
+func IsSafari(ua string) bool {
+	if !reSafari.MatchString(ua) {
+		return false
+	}
+	for _, token := range nonSafariTokens {
+		if strings.Contains(ua, token) {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectBrowser determines the browser with the default profiles.
+func DetectBrowser(h http.Header) BrowserKind {
+	return DefaultProfiles().DetectBrowser(h)
+}
+
+// torUserAgent is the User-Agent Tor Browser and Mullvad Browser send on every
+// desktop OS: Windows with the version of the Firefox ESR they are based on.
+var torUserAgent = regexp.MustCompile(`^Mozilla/5\.0 \(Windows NT 10\.0; Win64; x64; rv:([0-9]+)\.0\) Gecko/20100101 Firefox/([0-9]+)\.0$`)
+
+// torAcceptLanguage is the Accept-Language Tor Browser and Mullvad Browser
+// send when they spoof the locale, which they do by default.
+const torAcceptLanguage = "en-US,en;q=0.5"
+
+// FirefoxVariant tells Firefox for Android and Tor Browser apart from desktop
+// Firefox. The Tor fingerprint is a Firefox ESR on Windows with the spoofed
+// English locale, which an ordinary ESR user with these settings shares.
+func (p *Profiles) FirefoxVariant(h http.Header) BrowserKind {
+	ua := h.Get("User-Agent")
+	if IsAndroid(ua) || strings.Contains(ua, "(Android ") {
+		return BrowserFirefoxAndroid
+	}
+	if m := torUserAgent.FindStringSubmatch(ua); m != nil && m[1] == m[2] &&
+		h.Get("Accept-Language") == torAcceptLanguage && p.IsFirefoxESR(ua) {
+		return BrowserTor
+	}
+	return BrowserFirefox
+}
+
 // DetectBrowser determines the browser using User-Agent and Sec-CH-UA hints.
 // Order:
-//  1. Firefox (UA)
-//  2. Edge (UA: Edg/…)
-//  3. Brave (Chrome-like UA, but Sec-Ch-Ua or Sec-Ch-Ua-Full-Version-List contains "Brave")
-//  4. Chrome (UA)
-//  5. Unknown
-func DetectBrowser(h http.Header) BrowserKind {
+//  1. In-app browser or Android WebView (UA token or X-Requested-With, AppBrowser)
+//  2. Firefox (UA), Firefox for Android or Tor Browser (FirefoxVariant)
+//  3. Edge WebView2 (UA: Edg/…, "Microsoft Edge WebView2" brand), Edge (UA: Edg/…)
+//     or Edge for Android (UA: EdgA/…)
+//  4. Opera, Samsung Internet, Yandex Browser or Vivaldi (UA token, ChromiumDerivative)
+//  5. Chrome, Firefox or Edge on iPhone and iPad (UA token, IOSBrowser)
+//  6. Safari (UA: Version/… Safari/…, no other browser token)
+//  7. Brave (Chrome-like UA with the "Brave" brand in Sec-CH-UA, IsBrave)
+//  8. Chrome for Android (UA: Chrome/… with an Android platform token)
+//  9. Chrome (UA)
+//  10. Unknown
+func (p *Profiles) DetectBrowser(h http.Header) BrowserKind {
 	ua := h.Get("User-Agent")
-	secChUa := h.Get("Sec-Ch-Ua")
-	secChFullList := h.Get("Sec-Ch-Ua-Full-Version-List")
 
-	uaLower := strings.ToLower(ua)
-	secChLower := strings.ToLower(secChUa + " " + secChFullList)
+	// 1) Apps
+	if browser := AppBrowser(h); browser != BrowserUnknown {
+		return browser
+	}
 
-	// 1) Firefox
+	// 2) Firefox. TE: trailers is not required here: Firefox sends it over
+	// HTTP/2 only, so the profiles expect it as a protocol header.
 	if reFirefox.MatchString(ua) {
-		TeHttpheader := h.Get("Te")
-		if TeHttpheader == "trailers" {
-			return BrowserFirefox
-		}
+		return p.FirefoxVariant(h)
 	}
 
-	// 2) Edge
-	if reEdge.MatchString(ua) || strings.Contains(uaLower, "edg/") {
+	// 3) Edge
+	if IsEdge(ua) {
+		if Brands(h).Has(BrandEdgeWebView2) {
+			return BrowserEdgeWebView2
+		}
 		return BrowserEdge
 	}
+	if IsEdgeAndroid(ua) {
+		return BrowserEdgeAndroid
+	}
+
+	// 4) Other Chromium browsers with their own token
+	if browser := ChromiumDerivative(ua); browser != BrowserUnknown {
+		return browser
+	}
+
+	// 5) iOS browsers
+	if browser := IOSBrowser(ua); browser != BrowserUnknown {
+		return browser
+	}
 
-	// 3) Brave: look for "Brave" in client hints
-	if strings.Contains(secChLower, "brave") {
+	// 6) Safari
+	if IsSafari(ua) {
+		return BrowserSafari
+	}
+
+	// 7) Brave
+	if IsBrave(h) {
 		return BrowserBrave
 	}
 
-	// 4) Chrome
+	// 8) and 9) Chrome
 	if reChrome.MatchString(ua) {
+		if IsAndroid(ua) {
+			return BrowserChromeAndroid
+		}
 		return BrowserChrome
 	}
 
 	return BrowserUnknown
 }
 
-// ValidateHeaderLength enforces min/max header count per browser.
-//
-// Fill in *your actual* numbers here. Example:
-//
-//	Brave:  min 10, max 14
-//	Chrome: min 25, max 35
-//	Edge:   min 23, max 40
-//	Firefox:min  8, max 20
+// ValidateHeaderLength enforces min/max header count per browser
+// using the default profiles.
 func ValidateHeaderLength(h http.Header) HeaderCheckResult {
-	browser := DetectBrowser(h)
+	return ValidateHeaderLengthWithRanges(h, nil)
+}
+
+// ValidateHeaderLengthWithRanges enforces min/max header count per browser.
+// Entries in ranges override the default profiles for that browser.
+func ValidateHeaderLengthWithRanges(h http.Header, ranges map[BrowserKind]HeaderRange) HeaderCheckResult {
+	return ValidateHeaderLengthWithProfiles(h, DefaultProfiles(), ranges)
+}
+
+// ValidateHeaderLengthWithProfiles enforces the header count of the profile
+// matching the browser and version. Entries in ranges override the profiles
+// for that browser.
+func ValidateHeaderLengthWithProfiles(h http.Header, profiles *Profiles, ranges map[BrowserKind]HeaderRange) HeaderCheckResult {
+	browser, bp, found := profiles.LookupRequest(h)
 	headerLen := len(h)
 
-	var min, max int
-	switch browser {
-	case BrowserBrave:
-		min, max = 16, 23
-	case BrowserChrome:
-		min, max = 27, 32
-	case BrowserEdge:
-		min, max = 25, 30
-	case BrowserFirefox:
-		min, max = 9, 13
-	default:
+	limits, ok := ranges[browser]
+	if !ok && found && bp.HeaderCount != nil {
+		limits, ok = *bp.HeaderCount, true
+	}
+	if !ok {
 		// No constraints for unknown -> always "ok"
 		return HeaderCheckResult{
 			Browser:    browser,
 			HeaderLen:  headerLen,
 			WithinSpec: true,
-			Reason:     "no constraints for unknown browser",
+			Reason:     "no constraints for browser and version",
 		}
 	}
+	min, max := limits.Min, limits.Max
 
 	if headerLen < min {
 		return HeaderCheckResult{
 			Browser:    browser,
 			HeaderLen:  headerLen,
+			Min:        min,
+			Max:        max,
 			WithinSpec: false,
 			Reason:     fmt.Sprintf("too few headers: %d < %d", headerLen, min),
 		}
@@ -115,6 +294,8 @@ func ValidateHeaderLength(h http.Header) HeaderCheckResult {
 		return HeaderCheckResult{
 			Browser:    browser,
 			HeaderLen:  headerLen,
+			Min:        min,
+			Max:        max,
 			WithinSpec: false,
 			Reason:     fmt.Sprintf("too many headers: %d > %d", headerLen, max),
 		}
@@ -123,6 +304,8 @@ func ValidateHeaderLength(h http.Header) HeaderCheckResult {
 	return HeaderCheckResult{
 		Browser:    browser,
 		HeaderLen:  headerLen,
+		Min:        min,
+		Max:        max,
 		WithinSpec: true,
 		Reason:     "within expected range",
 	}
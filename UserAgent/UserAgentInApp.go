@@ -0,0 +1,71 @@
+package useragent
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// inAppTokens are the UA tokens social apps add to their in-app browser.
+var inAppTokens = []string{
+	// Facebook and Messenger
+	"FBAN/", "FBAV/", "FB_IAB/",
+	// Instagram
+	"Instagram ",
+	// TikTok
+	"BytedanceWebview/", "musical_ly_", "trill_",
+	// LinkedIn
+	"LinkedInApp",
+}
+
+// reAppPackage is an Android application ID, e.g. com.instagram.android.
+var reAppPackage = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*(\.[A-Za-z][A-Za-z0-9_]*)+$`)
+
+// IsInApp reports whether ua carries the token of a social app's in-app browser.
+func IsInApp(ua string) bool {
+	for _, token := range inAppTokens {
+		if strings.Contains(ua, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAndroidWebView reports whether ua carries the "; wv)" token of Android WebView.
+func IsAndroidWebView(ua string) bool {
+	return IsAndroid(ua) && strings.Contains(ua, "; wv)")
+}
+
+// IsAppPackage reports whether s is an Android application ID.
+func IsAppPackage(s string) bool {
+	return reAppPackage.MatchString(s)
+}
+
+// AppPackage returns the Android application ID in X-Requested-With, which
+// WebView adds for the app that embeds it. Values such as XMLHttpRequest,
+// set by scripts, are not application IDs.
+func AppPackage(h http.Header) (string, bool) {
+	v := strings.TrimSpace(h.Get("X-Requested-With"))
+	if !IsAppPackage(v) {
+		return "", false
+	}
+	return v, true
+}
+
+// AppBrowser returns in_app for the in-app browsers of social apps and
+// android_webview for other apps that embed Android WebView, recognized by
+// the "; wv)" token or an application ID in X-Requested-With on Android.
+// Other requests return BrowserUnknown.
+func AppBrowser(h http.Header) BrowserKind {
+	ua := h.Get("User-Agent")
+	if IsInApp(ua) {
+		return BrowserInApp
+	}
+	if IsAndroidWebView(ua) {
+		return BrowserAndroidWebView
+	}
+	if _, ok := AppPackage(h); ok && IsAndroid(ua) && reChrome.MatchString(ua) {
+		return BrowserAndroidWebView
+	}
+	return BrowserUnknown
+}
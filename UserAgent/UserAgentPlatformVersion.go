@@ -0,0 +1,145 @@
+package useragent
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PlatformVersionRule describes the Sec-CH-UA-Platform-Version one platform
+// reports. The reduced User-Agent freezes the OS version, e.g. to Mac OS X
+// 10_15_7 or CrOS 14541.0.0, while the hint carries the real one.
+type PlatformVersionRule struct {
+	// Empty says the platform reports an empty version, as Linux does.
+	Empty bool `json:"empty,omitempty"`
+
+	// Majors lists the major versions the releases of the platform report.
+	// A major version below the highest that is not listed fails; one above
+	// it passes as a newer release.
+	Majors []int `json:"majors,omitempty"`
+
+	// MinMajor is the lowest accepted major version, for platforms whose
+	// versions are build numbers, such as ChromeOS.
+	MinMajor int `json:"min_major,omitempty"`
+
+	// Frozen lists the versions of the reduced User-Agent, which the
+	// platform never reports as its real version, e.g. 10.15.7 on macOS.
+	Frozen []string `json:"frozen,omitempty"`
+
+	// UserAgent is the platform token the User-Agent must carry.
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// RequiresModel says Sec-CH-UA-Model is sent with the version. Both are
+	// high-entropy hints a page requests together.
+	RequiresModel bool `json:"requires_model,omitempty"`
+
+	// FrozenModels lists the models of the reduced User-Agent, e.g. K on
+	// Android, which no device reports as its real model.
+	FrozenModels []string `json:"frozen_models,omitempty"`
+}
+
+var rePlatformVersion = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)$`)
+
+func (rule PlatformVersionRule) validate() error {
+	if rule.Empty && (len(rule.Majors) > 0 || rule.MinMajor != 0 || len(rule.Frozen) > 0) {
+		return fmt.Errorf("an empty version has no majors, min_major or frozen versions")
+	}
+	if rule.MinMajor < 0 || slices.ContainsFunc(rule.Majors, func(v int) bool { return v <= 0 }) {
+		return fmt.Errorf("major versions must be positive")
+	}
+	for _, v := range rule.Frozen {
+		if !rePlatformVersion.MatchString(v) {
+			return fmt.Errorf("frozen: %q is not a major.minor.patch version", v)
+		}
+	}
+	if slices.Contains(rule.FrozenModels, "") {
+		return fmt.Errorf("frozen_models: value must not be empty")
+	}
+	return nil
+}
+
+// ValidateVersion checks the unquoted Sec-CH-UA-Platform-Version version.
+func (rule PlatformVersionRule) ValidateVersion(version string) error {
+	if rule.Empty {
+		if version != "" {
+			return fmt.Errorf("%q instead of an empty version", version)
+		}
+		return nil
+	}
+	m := rePlatformVersion.FindStringSubmatch(version)
+	if m == nil {
+		return fmt.Errorf("%q is not a major.minor.patch version", version)
+	}
+	if slices.Contains(rule.Frozen, version) {
+		return fmt.Errorf("%s is the frozen version of the reduced User-Agent", version)
+	}
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return fmt.Errorf("%q: %v", version, err)
+	}
+	if major < rule.MinMajor {
+		return fmt.Errorf("%s is below the lowest supported version %d", version, rule.MinMajor)
+	}
+	if len(rule.Majors) > 0 && !slices.Contains(rule.Majors, major) {
+		if major < slices.Min(rule.Majors) {
+			return fmt.Errorf("%s is older than the oldest supported release %d", version, slices.Min(rule.Majors))
+		}
+		if major < slices.Max(rule.Majors) {
+			return fmt.Errorf("%s is no known release", version)
+		}
+	}
+	return nil
+}
+
+// PlatformVersion returns the rule for the Sec-CH-UA-Platform value platform.
+func (p *Profiles) PlatformVersion(platform string) (PlatformVersionRule, bool) {
+	rule, ok := p.PlatformVersions[platform]
+	return rule, ok
+}
+
+// ValidatePlatformVersion checks Sec-CH-UA-Platform-Version against the rule
+// of the platform in Sec-CH-UA-Platform, the platform token of the User-Agent
+// and Sec-CH-UA-Model. Requests without a version, platforms without a rule
+// and malformed hints pass.
+func (p *Profiles) ValidatePlatformVersion(h http.Header) error {
+	platformVersion := h.Get("Sec-CH-UA-Platform-Version")
+	if platformVersion == "" {
+		return nil
+	}
+	platform, err := ParseHintString(h.Get("Sec-CH-UA-Platform"))
+	if err != nil {
+		return nil
+	}
+	rule, ok := p.PlatformVersion(platform)
+	if !ok {
+		return nil
+	}
+	version, err := ParseHintString(platformVersion)
+	if err != nil {
+		return nil
+	}
+	if err := rule.ValidateVersion(version); err != nil {
+		return fmt.Errorf("%s: %v", platform, err)
+	}
+	if rule.UserAgent != "" && !strings.Contains(h.Get("User-Agent"), rule.UserAgent) {
+		return fmt.Errorf("%s platform without the %q User-Agent token", platform, rule.UserAgent)
+	}
+	if !rule.RequiresModel && len(rule.FrozenModels) == 0 {
+		return nil
+	}
+	model, sent := h["Sec-Ch-Ua-Model"]
+	if !sent {
+		if rule.RequiresModel {
+			return fmt.Errorf("%s platform version without Sec-CH-UA-Model", platform)
+		}
+		return nil
+	}
+	value, err := ParseHintString(model[0])
+	if err == nil && slices.Contains(rule.FrozenModels, value) {
+		return fmt.Errorf("%s: model %s is the frozen model of the reduced User-Agent", platform, value)
+	}
+	return nil
+}
@@ -0,0 +1,450 @@
+package useragent
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProfileFormatVersion is the profile file format this package reads.
+const ProfileFormatVersion = 1
+
+// Destinations used as keys of BrowserProfile.Accept.
+const (
+	DestinationDocument = "document"
+	DestinationImage    = "image"
+)
+
+//go:embed profiles.json
+var defaultProfilesJSON []byte
+
+// Profiles describes what current browsers send. It is loaded from a JSON
+// file, so a new browser release only needs a data change.
+type Profiles struct {
+	// Version is the file format version. It must be ProfileFormatVersion.
+	Version int `json:"version"`
+
+	// Revision identifies the data, e.g. the date it was last updated.
+	Revision string `json:"revision,omitempty"`
+
+	// Brands are the Sec-CH-UA brands of mainstream browsers.
+	Brands []string `json:"brands"`
+
+	// Platforms are the platform tokens of reduced User-Agent strings.
+	Platforms []string `json:"platforms"`
+
+	// PlatformTokens maps each Sec-CH-UA-Platform value to the platform
+	// tokens a User-Agent sent with it may carry. Every token is a prefix of
+	// one of Platforms. An empty list accepts any platform token. Files
+	// without it use the map of the embedded profiles.
+	PlatformTokens map[string][]string `json:"platform_tokens,omitempty"`
+
+	// FirefoxESR lists the major versions of supported Firefox ESR releases.
+	// They are accepted below the Firefox version floor.
+	FirefoxESR []int `json:"firefox_esr,omitempty"`
+
+	// PlatformVersions maps a Sec-CH-UA-Platform value to the versions the
+	// platform reports in Sec-CH-UA-Platform-Version. Windows has a check of
+	// its own.
+	PlatformVersions map[string]PlatformVersionRule `json:"platform_versions,omitempty"`
+
+	// Browsers holds the profiles of each browser family, by version range.
+	Browsers map[BrowserKind][]BrowserProfile `json:"browsers"`
+}
+
+// BrowserProfile describes the requests of one browser family for a range of
+// major versions.
+type BrowserProfile struct {
+	// MinVersion and MaxVersion bound the major versions. Zero means unbounded.
+	MinVersion int `json:"min_version,omitempty"`
+	MaxVersion int `json:"max_version,omitempty"`
+
+	// UserAgent is a regular expression the User-Agent must match.
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Accept maps a destination (document, image) to the expected Accept value.
+	Accept map[string]string `json:"accept,omitempty"`
+
+	// AcceptVariants maps a destination to further Accept values the browser
+	// sends next to Accept, e.g. depending on the platform.
+	AcceptVariants map[string][]string `json:"accept_variants,omitempty"`
+
+	// AcceptEncoding lists the accepted Accept-Encoding values.
+	AcceptEncoding []string `json:"accept_encoding,omitempty"`
+
+	// HeaderCount is the accepted number of request headers.
+	HeaderCount *HeaderRange `json:"header_count,omitempty"`
+
+	// RequiredHeaders must be present in every request.
+	RequiredHeaders []string `json:"required_headers,omitempty"`
+
+	// HeaderValues maps headers to the only value the browser sends, e.g.
+	// Sec-GPC: 1 for Brave. Absent headers are left to RequiredHeaders.
+	HeaderValues map[string]string `json:"header_values,omitempty"`
+
+	// ProtocolHeaders must be present in requests over one HTTP version,
+	// keyed by its major version: "1", "2" or "3". Firefox, for example,
+	// sends TE: trailers over HTTP/2 but not always over HTTP/1.1 and HTTP/3.
+	ProtocolHeaders map[string][]string `json:"protocol_headers,omitempty"`
+
+	// OptionalHeaders may be present next to RequiredHeaders. When set, any
+	// other header is unexpected. An empty list allows every header.
+	OptionalHeaders []string `json:"optional_headers,omitempty"`
+
+	// ForbiddenHeaders must not be present. A trailing * matches any suffix,
+	// e.g. "Sec-Ch-Ua*" for all UA client hints.
+	ForbiddenHeaders []string `json:"forbidden_headers,omitempty"`
+
+	// SecFetch says whether the browser sends Sec-Fetch headers. Default: true.
+	SecFetch *bool `json:"sec_fetch,omitempty"`
+
+	ClientHints *ClientHintRules `json:"client_hints,omitempty"`
+
+	userAgentRe *regexp.Regexp
+}
+
+// ClientHintRules describes the client hints a browser sends.
+type ClientHintRules struct {
+	// DeviceMemory lists the accepted Sec-CH-Device-Memory values.
+	DeviceMemory []string `json:"device_memory,omitempty"`
+
+	// Optional lists client hint headers the browser may leave out,
+	// e.g. because it does not support them.
+	Optional []string `json:"optional,omitempty"`
+
+	// Platforms lists the accepted Sec-CH-UA-Platform values, without quotes.
+	Platforms []string `json:"platforms,omitempty"`
+
+	// Model says what Sec-CH-UA-Model must be when it is sent: ModelEmpty
+	// or ModelNonEmpty. Empty means any value.
+	Model string `json:"model,omitempty"`
+}
+
+// Sec-CH-UA-Model rules.
+const (
+	ModelEmpty    = "empty"
+	ModelNonEmpty = "non_empty"
+)
+
+var defaultProfiles = sync.OnceValue(func() *Profiles {
+	p, err := parseProfiles(defaultProfilesJSON)
+	if err == nil && len(p.PlatformTokens) == 0 {
+		err = fmt.Errorf("platform_tokens must not be empty")
+	}
+	if err != nil {
+		panic("useragent: invalid embedded profiles: " + err.Error())
+	}
+	return p
+})
+
+// DefaultProfiles returns the profiles embedded in the module.
+func DefaultProfiles() *Profiles {
+	return defaultProfiles()
+}
+
+// ParseProfiles decodes and validates a profile file. A file without
+// platform_tokens keeps the map of the embedded profiles, so loading it does
+// not turn off the platform token checks.
+func ParseProfiles(data []byte) (*Profiles, error) {
+	p, err := parseProfiles(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.PlatformTokens) == 0 {
+		p.PlatformTokens = DefaultProfiles().PlatformTokens
+	}
+	return p, nil
+}
+
+func parseProfiles(data []byte) (*Profiles, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p Profiles
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	for _, profiles := range p.Browsers {
+		for i := range profiles {
+			if profiles[i].UserAgent != "" {
+				profiles[i].userAgentRe = regexp.MustCompile(profiles[i].UserAgent)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// LoadProfiles reads a profile file from disk.
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParseProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return p, nil
+}
+
+// Validate checks the profiles for unknown names and impossible ranges.
+func (p *Profiles) Validate() error {
+	if p.Version != ProfileFormatVersion {
+		return fmt.Errorf("unsupported profile version %d, want %d", p.Version, ProfileFormatVersion)
+	}
+	if len(p.Brands) == 0 {
+		return fmt.Errorf("brands must not be empty")
+	}
+	if len(p.Platforms) == 0 {
+		return fmt.Errorf("platforms must not be empty")
+	}
+	for _, v := range p.FirefoxESR {
+		if v <= 0 {
+			return fmt.Errorf("firefox_esr: invalid version %d", v)
+		}
+	}
+	for _, platform := range slices.Sorted(maps.Keys(p.PlatformTokens)) {
+		for _, token := range p.PlatformTokens[platform] {
+			if !slices.ContainsFunc(p.Platforms, func(pattern string) bool { return token != "" && strings.HasPrefix(pattern, token) }) {
+				return fmt.Errorf("platform_tokens %s: %q is no prefix of a platform token", platform, token)
+			}
+		}
+	}
+	for _, platform := range slices.Sorted(maps.Keys(p.PlatformVersions)) {
+		if err := p.PlatformVersions[platform].validate(); err != nil {
+			return fmt.Errorf("platform_versions %s: %v", platform, err)
+		}
+	}
+	for _, browser := range slices.Sorted(maps.Keys(p.Browsers)) {
+		if !IsBrowserKind(browser) {
+			return fmt.Errorf("unknown browser %q", browser)
+		}
+		for i, bp := range p.Browsers[browser] {
+			if err := bp.validate(); err != nil {
+				return fmt.Errorf("browser %s, profile %d: %v", browser, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (bp BrowserProfile) validate() error {
+	if bp.MinVersion < 0 || bp.MaxVersion < 0 {
+		return fmt.Errorf("versions must not be negative")
+	}
+	if bp.MaxVersion != 0 && bp.MinVersion > bp.MaxVersion {
+		return fmt.Errorf("min_version %d is greater than max_version %d", bp.MinVersion, bp.MaxVersion)
+	}
+	if bp.UserAgent != "" {
+		if _, err := regexp.Compile(bp.UserAgent); err != nil {
+			return fmt.Errorf("user_agent: %v", err)
+		}
+	}
+	for dest, accept := range bp.Accept {
+		if dest != DestinationDocument && dest != DestinationImage {
+			return fmt.Errorf("accept: unknown destination %q", dest)
+		}
+		if strings.TrimSpace(accept) == "" {
+			return fmt.Errorf("accept %s: value must not be empty", dest)
+		}
+	}
+	for dest, variants := range bp.AcceptVariants {
+		if _, ok := bp.Accept[dest]; !ok {
+			return fmt.Errorf("accept_variants: no accept value for destination %q", dest)
+		}
+		if slices.Contains(variants, "") {
+			return fmt.Errorf("accept_variants %s: value must not be empty", dest)
+		}
+	}
+	for proto := range bp.ProtocolHeaders {
+		if proto != "1" && proto != "2" && proto != "3" {
+			return fmt.Errorf("protocol_headers: unknown HTTP version %q", proto)
+		}
+	}
+	if ch := bp.ClientHints; ch != nil && ch.Model != "" && ch.Model != ModelEmpty && ch.Model != ModelNonEmpty {
+		return fmt.Errorf("client_hints: unknown model rule %q", ch.Model)
+	}
+	if r := bp.HeaderCount; r != nil && (r.Min < 0 || r.Min > r.Max) {
+		return fmt.Errorf("header_count: invalid range %d-%d", r.Min, r.Max)
+	}
+	return nil
+}
+
+// Lookup returns the first profile of browser whose version range contains version.
+func (p *Profiles) Lookup(browser BrowserKind, version int) (BrowserProfile, bool) {
+	for _, bp := range p.Browsers[browser] {
+		if bp.Matches(version) {
+			return bp, true
+		}
+	}
+	return BrowserProfile{}, false
+}
+
+// LookupRequest returns the profile for the browser and version of a request.
+func (p *Profiles) LookupRequest(h http.Header) (BrowserKind, BrowserProfile, bool) {
+	browser := p.DetectBrowser(h)
+	version, ok := BrowserVersion(browser, h.Get("User-Agent"))
+	if !ok {
+		return browser, BrowserProfile{}, false
+	}
+	bp, ok := p.Lookup(browser, version)
+	return browser, bp, ok
+}
+
+// VersionRanges describes the version ranges of browser, e.g. "115-127,128-".
+func (p *Profiles) VersionRanges(browser BrowserKind) string {
+	ranges := make([]string, 0, len(p.Browsers[browser]))
+	for _, bp := range p.Browsers[browser] {
+		lo, hi := "", ""
+		if bp.MinVersion != 0 {
+			lo = strconv.Itoa(bp.MinVersion)
+		}
+		if bp.MaxVersion != 0 {
+			hi = strconv.Itoa(bp.MaxVersion)
+		}
+		ranges = append(ranges, lo+"-"+hi)
+	}
+	return strings.Join(ranges, ",")
+}
+
+// Matches reports whether version is within the version range of bp.
+func (bp BrowserProfile) Matches(version int) bool {
+	return version >= bp.MinVersion && (bp.MaxVersion == 0 || version <= bp.MaxVersion)
+}
+
+// Required returns the headers a request over HTTP version protoMajor must
+// carry: RequiredHeaders and the ProtocolHeaders of that version.
+func (bp BrowserProfile) Required(protoMajor int) []string {
+	return slices.Concat(bp.RequiredHeaders, bp.ProtocolHeaders[strconv.Itoa(protoMajor)])
+}
+
+// MatchesUserAgent reports whether ua matches the UserAgent pattern of bp.
+// Profiles without a pattern match every User-Agent.
+func (bp BrowserProfile) MatchesUserAgent(ua string) bool {
+	return bp.userAgentRe == nil || bp.userAgentRe.MatchString(ua)
+}
+
+// SendsSecFetch reports whether the browser sends Sec-Fetch headers.
+func (bp BrowserProfile) SendsSecFetch() bool {
+	return bp.SecFetch == nil || *bp.SecFetch
+}
+
+// ForbiddenHeader returns the first header in h that the profile forbids.
+func (bp BrowserProfile) ForbiddenHeader(h http.Header) (string, bool) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, pattern := range bp.ForbiddenHeaders {
+		prefix, wildcard := strings.CutSuffix(http.CanonicalHeaderKey(pattern), "*")
+		for _, name := range names {
+			if name == prefix || (wildcard && strings.HasPrefix(name, prefix)) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ClientHintPlatforms returns the accepted Sec-CH-UA-Platform values.
+func (bp BrowserProfile) ClientHintPlatforms() []string {
+	if bp.ClientHints == nil {
+		return nil
+	}
+	return bp.ClientHints.Platforms
+}
+
+// ModelRule returns the Sec-CH-UA-Model rule of the profile.
+func (bp BrowserProfile) ModelRule() string {
+	if bp.ClientHints == nil {
+		return ""
+	}
+	return bp.ClientHints.Model
+}
+
+// OptionalHint reports whether the browser may leave out client hint header name.
+func (bp BrowserProfile) OptionalHint(name string) bool {
+	if bp.ClientHints == nil {
+		return false
+	}
+	for _, hint := range bp.ClientHints.Optional {
+		if strings.EqualFold(hint, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceMemory returns the accepted Sec-CH-Device-Memory values.
+func (bp BrowserProfile) DeviceMemory() []string {
+	if bp.ClientHints == nil {
+		return nil
+	}
+	return bp.ClientHints.DeviceMemory
+}
+
+// IsFirefoxESR reports whether ua is a Firefox with the major version of a
+// supported ESR release.
+func (p *Profiles) IsFirefoxESR(ua string) bool {
+	v, ok := majorVersion(firefoxMajorRe, ua)
+	return ok && slices.Contains(p.FirefoxESR, v)
+}
+
+// IsBrowserKind reports whether b is a known browser.
+func IsBrowserKind(b BrowserKind) bool {
+	switch b {
+	case BrowserChrome, BrowserChromeAndroid, BrowserEdge, BrowserEdgeWebView2, BrowserEdgeAndroid, BrowserFirefox,
+		BrowserFirefoxAndroid, BrowserTor, BrowserBrave, BrowserOpera, BrowserVivaldi, BrowserSamsung,
+		BrowserYandex, BrowserSafari, BrowserChromeIOS, BrowserFirefoxIOS, BrowserEdgeIOS,
+		BrowserAndroidWebView, BrowserInApp:
+		return true
+	}
+	return false
+}
+
+// BrowserVersion returns the major version of browser in ua.
+func BrowserVersion(browser BrowserKind, ua string) (int, bool) {
+	switch browser {
+	case BrowserChrome, BrowserChromeAndroid, BrowserBrave, BrowserVivaldi, BrowserAndroidWebView:
+		return majorVersion(chromeMajorRe, ua)
+	case BrowserOpera:
+		return majorVersion(operaMajorRe, ua)
+	case BrowserSamsung:
+		return majorVersion(samsungMajorRe, ua)
+	case BrowserYandex:
+		return majorVersion(yandexMajorRe, ua)
+	case BrowserEdge, BrowserEdgeWebView2, BrowserEdgeAndroid:
+		return majorVersion(edgeMajorRe, ua)
+	case BrowserFirefox, BrowserFirefoxAndroid, BrowserTor:
+		return majorVersion(firefoxMajorRe, ua)
+	case BrowserSafari:
+		return majorVersion(safariMajorRe, ua)
+	case BrowserInApp:
+		// In-app browsers have no version of their own. On Android they
+		// follow the Chrome/ token of the WebView they are built on.
+		if version, ok := majorVersion(chromeMajorRe, ua); ok {
+			return version, true
+		}
+		return 0, true
+	case BrowserChromeIOS:
+		return majorVersion(chromeIOSMajorRe, ua)
+	case BrowserFirefoxIOS:
+		return majorVersion(firefoxIOSMajorRe, ua)
+	case BrowserEdgeIOS:
+		return majorVersion(edgeIOSMajorRe, ua)
+	}
+	return 0, false
+}
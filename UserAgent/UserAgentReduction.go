@@ -1,6 +1,10 @@
 package useragent
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
 
 type UserAgentReduction string
 
@@ -14,25 +18,49 @@ const (
 	PlatformIpad     UserAgentReduction = "iPad; CPU OS 18_7 like Mac OS X"
 )
 
-var allReducedPatterns = []UserAgentReduction{
-	PlatformAndroid,
-	PlatformMac,
-	PlatformWindows,
-	PlatformChromeOS,
-	PlatformLinux,
-	PlatformIphone,
-	PlatformIpad,
+// ValidateReduction reports whether ua carries one of the platform tokens of
+// the default profiles.
+func ValidateReduction(ua string) bool {
+	return DefaultProfiles().ValidateReduction(ua)
 }
 
-func ValidateReduction(ua string) bool {
-	if strings.Contains(ua, "iPhone; CPU iPhone OS") && (strings.Contains(ua, "CriOS") || strings.Contains(ua, "EdgiOS")) {
+// reIOSPlatform is the platform token of browsers on iPhone and iPad, which
+// carries the real OS version instead of a reduced one.
+var reIOSPlatform = regexp.MustCompile(`\((iPhone; CPU iPhone OS|iPad; CPU OS) \d+_\d+(_\d+)? like Mac OS X\)`)
+
+// ValidateReduction reports whether ua carries one of the platform tokens in p.
+func (p *Profiles) ValidateReduction(ua string) bool {
+	if (IsSafari(ua) || IOSBrowser(ua) != BrowserUnknown || IsInApp(ua)) && reIOSPlatform.MatchString(ua) {
+		return true
+	}
+	// Android WebView, which in-app browsers on Android build on, keeps the
+	// full platform token with the device model.
+	if (IsAndroidWebView(ua) || IsInApp(ua)) && IsAndroid(ua) {
 		return true
 	}
 
-	for _, pattern := range allReducedPatterns {
-		if strings.Contains(ua, string(pattern)) {
+	for _, pattern := range p.Platforms {
+		if strings.Contains(ua, pattern) {
 			return true
 		}
 	}
 	return false
 }
+
+// ValidatePlatformToken checks that ua carries a platform token that fits the
+// Sec-CH-UA-Platform value platform, e.g. a Windows token for "Windows".
+func (p *Profiles) ValidatePlatformToken(platform, ua string) error {
+	tokens, ok := p.PlatformTokens[platform]
+	if !ok {
+		return fmt.Errorf("unknown platform %q", platform)
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	for _, token := range tokens {
+		if strings.Contains(ua, token) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s platform with a User-Agent without %q", platform, strings.Join(tokens, `", "`))
+}
@@ -0,0 +1,172 @@
+package useragent
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReleaseFormatVersion is the release file format this package reads.
+const ReleaseFormatVersion = 1
+
+//go:embed releases.json
+var defaultReleasesJSON []byte
+
+// Releases holds the release dates of the major versions of each browser. It
+// is loaded from a JSON file, so a new release only needs a data change.
+type Releases struct {
+	// Version is the file format version. It must be ReleaseFormatVersion.
+	Version int `json:"version"`
+
+	// Revision identifies the data, e.g. the date it was last updated.
+	Revision string `json:"revision,omitempty"`
+
+	// Browsers maps a version floor key to the release date (YYYY-MM-DD) of
+	// each major version. Releases may lie in the future, e.g. from a
+	// published release schedule. Browsers on iPhone and iPad use the data
+	// of chrome, firefox and edge when they have none of their own.
+	Browsers map[string]map[string]string `json:"browsers"`
+
+	majors map[string][]release
+}
+
+// release is one major version and its release date.
+type release struct {
+	major int
+	date  time.Time
+}
+
+// iosReleaseKeys maps the floor keys of browsers on iPhone and iPad to the
+// desktop browser whose version numbers they share.
+var iosReleaseKeys = map[string]string{
+	FloorChromeIOS:  FloorChrome,
+	FloorFirefoxIOS: FloorFirefox,
+	FloorEdgeIOS:    FloorEdge,
+}
+
+var defaultReleases = sync.OnceValue(func() *Releases {
+	rs, err := ParseReleases(defaultReleasesJSON)
+	if err != nil {
+		panic("useragent: invalid embedded releases: " + err.Error())
+	}
+	return rs
+})
+
+// DefaultReleases returns the release data embedded in the module.
+func DefaultReleases() *Releases {
+	return defaultReleases()
+}
+
+// ParseReleases decodes and validates a release file.
+func ParseReleases(data []byte) (*Releases, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var rs Releases
+	if err := dec.Decode(&rs); err != nil {
+		return nil, err
+	}
+	if rs.Version != ReleaseFormatVersion {
+		return nil, fmt.Errorf("unsupported release file version %d, want %d", rs.Version, ReleaseFormatVersion)
+	}
+	rs.majors = make(map[string][]release, len(rs.Browsers))
+	for key, dates := range rs.Browsers {
+		if !IsFloorKey(key) {
+			return nil, fmt.Errorf("unknown browser %q", key)
+		}
+		for majorStr, dateStr := range dates {
+			major, err := strconv.Atoi(majorStr)
+			if err != nil || major <= 0 {
+				return nil, fmt.Errorf("browser %s: invalid major version %q", key, majorStr)
+			}
+			date, err := time.Parse(time.DateOnly, dateStr)
+			if err != nil {
+				return nil, fmt.Errorf("browser %s, version %d: invalid date %q", key, major, dateStr)
+			}
+			rs.majors[key] = append(rs.majors[key], release{major: major, date: date})
+		}
+		slices.SortFunc(rs.majors[key], func(a, b release) int { return a.major - b.major })
+	}
+	return &rs, nil
+}
+
+// LoadReleases reads a release file from disk.
+func LoadReleases(path string) (*Releases, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := ParseReleases(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return rs, nil
+}
+
+// releases returns the releases of key, sorted by major version.
+func (rs *Releases) releases(key string) []release {
+	if r, ok := rs.majors[key]; ok {
+		return r
+	}
+	return rs.majors[iosReleaseKeys[key]]
+}
+
+// Latest returns the highest major version of key released at now.
+func (rs *Releases) Latest(key string, now time.Time) (int, bool) {
+	latest, ok := 0, false
+	for _, r := range rs.releases(key) {
+		if !r.date.After(now) {
+			latest, ok = r.major, true
+		}
+	}
+	return latest, ok
+}
+
+// Projected returns the latest major version of key at now, like Latest.
+// Past the last release in the data it continues the average release cadence
+// of the data, so that data which is not kept up to date does not make every
+// current browser look unreleased.
+func (rs *Releases) Projected(key string, now time.Time) (int, bool) {
+	latest, ok := rs.Latest(key, now)
+	releases := rs.releases(key)
+	if !ok || len(releases) < 2 {
+		return latest, ok
+	}
+	first, last := releases[0], releases[len(releases)-1]
+	if !now.After(last.date) || !last.date.After(first.date) {
+		return latest, ok
+	}
+	cadence := last.date.Sub(first.date) / time.Duration(last.major-first.major)
+	return last.major + int(now.Sub(last.date)/cadence), true
+}
+
+// SupersededAt returns the date the first major version of key after major
+// was released.
+func (rs *Releases) SupersededAt(key string, major int) (time.Time, bool) {
+	for _, r := range rs.releases(key) {
+		if r.major > major {
+			return r.date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// floorKeyOrder lists the floor keys from the most to the least specific UA
+// token: Samsung Internet and Edge also carry Chrome/, for example.
+var floorKeyOrder = []string{FloorSamsung, FloorEdgeIOS, FloorChromeIOS, FloorFirefoxIOS, FloorEdge, FloorFirefox, FloorChrome}
+
+// FloorKey returns the version floor key of the browser in ua and its major
+// version.
+func FloorKey(ua string) (string, int, bool) {
+	for _, key := range floorKeyOrder {
+		if v, ok := majorVersion(floorPatterns[key], ua); ok {
+			return key, v, true
+		}
+	}
+	return "", 0, false
+}
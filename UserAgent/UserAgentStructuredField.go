@@ -0,0 +1,319 @@
+package useragent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Structured Field Values for HTTP (RFC 8941). The UA client hints are
+// structured fields: Sec-CH-UA is a list of sf-strings with a v parameter,
+// Sec-CH-UA-Platform an sf-string and Sec-CH-UA-Mobile an sf-boolean.
+
+// Token is an sf-token bare item, such as the value of a parameter written
+// without quotes.
+type Token string
+
+// Param is one parameter of an item or inner list.
+type Param struct {
+	Key   string
+	Value any
+}
+
+// Params are the parameters of an item or inner list, in order.
+type Params []Param
+
+// Get returns the value of the parameter key.
+func (ps Params) Get(key string) (any, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Item is an sf-item: a bare item and its parameters. Value is a string,
+// Token, int64, float64, bool or []byte. In a list, an inner list is an Item
+// whose Value is a []Item.
+type Item struct {
+	Value  any
+	Params Params
+}
+
+// ParseList parses an sf-list. An empty field is an empty list.
+func ParseList(field string) ([]Item, error) {
+	p := sfParser{s: strings.TrimLeft(field, " ")}
+	var list []Item
+	for !p.done() {
+		member, err := p.itemOrInnerList()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, member)
+		p.skipOWS()
+		if p.done() {
+			break
+		}
+		if p.s[p.i] != ',' {
+			return nil, p.errorf("expected a comma after a list member")
+		}
+		p.i++
+		p.skipOWS()
+		if p.done() {
+			return nil, p.errorf("trailing comma")
+		}
+	}
+	return list, nil
+}
+
+// ParseItem parses an sf-item.
+func ParseItem(field string) (Item, error) {
+	p := sfParser{s: strings.TrimLeft(field, " ")}
+	item, err := p.item()
+	if err != nil {
+		return Item{}, err
+	}
+	p.skipSP()
+	if !p.done() {
+		return Item{}, p.errorf("unexpected %q after the item", p.s[p.i])
+	}
+	return item, nil
+}
+
+// sfParser reads a structured field from s, starting at i.
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *sfParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("structured field: offset %d: %s", p.i, fmt.Sprintf(format, args...))
+}
+
+func (p *sfParser) skipSP() {
+	for !p.done() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for !p.done() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *sfParser) itemOrInnerList() (Item, error) {
+	if !p.done() && p.s[p.i] == '(' {
+		return p.innerList()
+	}
+	return p.item()
+}
+
+func (p *sfParser) innerList() (Item, error) {
+	p.i++ // (
+	var items []Item
+	for {
+		p.skipSP()
+		if p.done() {
+			return Item{}, p.errorf("unterminated inner list")
+		}
+		if p.s[p.i] == ')' {
+			p.i++
+			params, err := p.params()
+			if err != nil {
+				return Item{}, err
+			}
+			return Item{Value: items, Params: params}, nil
+		}
+		item, err := p.item()
+		if err != nil {
+			return Item{}, err
+		}
+		items = append(items, item)
+		if p.done() || (p.s[p.i] != ' ' && p.s[p.i] != ')') {
+			return Item{}, p.errorf("expected a space or ) in an inner list")
+		}
+	}
+}
+
+func (p *sfParser) item() (Item, error) {
+	value, err := p.bareItem()
+	if err != nil {
+		return Item{}, err
+	}
+	params, err := p.params()
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{Value: value, Params: params}, nil
+}
+
+func (p *sfParser) params() (Params, error) {
+	var params Params
+	for !p.done() && p.s[p.i] == ';' {
+		p.i++
+		p.skipSP()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var value any = true
+		if !p.done() && p.s[p.i] == '=' {
+			p.i++
+			if value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		// a repeated key overwrites the earlier value in place
+		replaced := false
+		for j := range params {
+			if params[j].Key == key {
+				params[j].Value, replaced = value, true
+			}
+		}
+		if !replaced {
+			params = append(params, Param{Key: key, Value: value})
+		}
+	}
+	return params, nil
+}
+
+func (p *sfParser) key() (string, error) {
+	start := p.i
+	if p.done() || !(isLCAlpha(p.s[p.i]) || p.s[p.i] == '*') {
+		return "", p.errorf("a key must start with a lowercase letter or *")
+	}
+	for !p.done() {
+		c := p.s[p.i]
+		if !isLCAlpha(c) && !isDigit(c) && !strings.ContainsRune("_-.*", rune(c)) {
+			break
+		}
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) bareItem() (any, error) {
+	if p.done() {
+		return nil, p.errorf("missing item")
+	}
+	switch c := p.s[p.i]; {
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.string()
+	case isAlpha(c) || c == '*':
+		return p.token(), nil
+	case c == ':':
+		return p.byteSequence()
+	case c == '?':
+		return p.boolean()
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *sfParser) number() (any, error) {
+	start := p.i
+	if p.s[p.i] == '-' {
+		p.i++
+	}
+	digits, dot := 0, -1
+	for ; !p.done(); p.i++ {
+		c := p.s[p.i]
+		if c == '.' && dot < 0 {
+			if digits > 12 {
+				return nil, p.errorf("a decimal has at most 12 integer digits")
+			}
+			dot = digits
+			continue
+		}
+		if !isDigit(c) {
+			break
+		}
+		digits++
+	}
+	switch {
+	case digits == 0:
+		return nil, p.errorf("missing digits")
+	case dot < 0 && digits > 15:
+		return nil, p.errorf("an integer has at most 15 digits")
+	case dot < 0:
+		return strconv.ParseInt(p.s[start:p.i], 10, 64)
+	case p.s[p.i-1] == '.':
+		return nil, p.errorf("a decimal cannot end in a dot")
+	case digits-dot > 3:
+		return nil, p.errorf("a decimal has at most 3 fractional digits")
+	}
+	return strconv.ParseFloat(p.s[start:p.i], 64)
+}
+
+func (p *sfParser) string() (string, error) {
+	p.i++ // "
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.done() || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+				return "", p.errorf("invalid escape in a string")
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			p.i--
+			return "", p.errorf("invalid character %q in a string", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *sfParser) token() Token {
+	start := p.i
+	for p.i++; !p.done() && (isTChar(p.s[p.i]) || p.s[p.i] == ':' || p.s[p.i] == '/'); p.i++ {
+	}
+	return Token(p.s[start:p.i])
+}
+
+func (p *sfParser) byteSequence() ([]byte, error) {
+	p.i++ // :
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, p.errorf("unterminated byte sequence")
+	}
+	data, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+	if err != nil {
+		return nil, p.errorf("invalid byte sequence: %v", err)
+	}
+	p.i += end + 1
+	return data, nil
+}
+
+func (p *sfParser) boolean() (bool, error) {
+	p.i++ // ?
+	if p.done() || (p.s[p.i] != '0' && p.s[p.i] != '1') {
+		return false, p.errorf("a boolean is ?0 or ?1")
+	}
+	p.i++
+	return p.s[p.i-1] == '1', nil
+}
+
+func isDigit(c byte) bool   { return '0' <= c && c <= '9' }
+func isLCAlpha(c byte) bool { return 'a' <= c && c <= 'z' }
+func isAlpha(c byte) bool   { return isLCAlpha(c) || ('A' <= c && c <= 'Z') }
+
+// isTChar reports whether c is a tchar of RFC 9110.
+func isTChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
@@ -1,38 +1,127 @@
 package useragent
 
-import "regexp"
+import (
+	"regexp"
+	"strconv"
+)
+
+// Version floor keys. They name the UA token that carries the version.
+const (
+	FloorChrome     = "chrome"
+	FloorFirefox    = "firefox"
+	FloorEdge       = "edge"
+	FloorChromeIOS  = "chrome_ios"
+	FloorFirefoxIOS = "firefox_ios"
+	FloorEdgeIOS    = "edge_ios"
+	FloorSamsung    = "samsung"
+)
+
+// VersionFloors maps a floor key to the lowest accepted major version.
+type VersionFloors map[string]int
+
+// DefaultVersionFloors are used for every key missing from a configured VersionFloors.
+var DefaultVersionFloors = VersionFloors{
+	FloorChrome:     140,
+	FloorFirefox:    140,
+	FloorEdge:       140,
+	FloorChromeIOS:  140,
+	FloorFirefoxIOS: 140,
+	FloorEdgeIOS:    140,
+	FloorSamsung:    27,
+}
 
 var (
-	oldChromeRe     = regexp.MustCompile(`Chrome/([0-9]{1,2}|1[0-3][0-9])\.[0-9]`)
-	oldFirefoxRe    = regexp.MustCompile(`Firefox/([0-9]{1,2}|1[0-3][0-9])\.[0-9]`)
-	oldEdgeRe       = regexp.MustCompile(`Edg/([0-9]{1,2}|1[0-3][0-9])\.[0-9]`)
-	oldFirefoxIOSRe = regexp.MustCompile(`FxiOS/([0-9]{1,2}|1[0-3][0-9])\.[0-9]`)
-	oldChromeIOSRe  = regexp.MustCompile(`CriOS/([0-9]{1,2}|1[0-3][0-9])\.[0-9]`)
+	chromeMajorRe     = regexp.MustCompile(`Chrome/([0-9]+)\.[0-9]`)
+	firefoxMajorRe    = regexp.MustCompile(`Firefox/([0-9]+)\.[0-9]`)
+	edgeMajorRe       = regexp.MustCompile(`EdgA?/([0-9]+)\.[0-9]`) // Edge and Edge for Android
+	firefoxIOSMajorRe = regexp.MustCompile(`FxiOS/([0-9]+)\.[0-9]`)
+	chromeIOSMajorRe  = regexp.MustCompile(`CriOS/([0-9]+)\.[0-9]`)
+	edgeIOSMajorRe    = regexp.MustCompile(`EdgiOS/([0-9]+)\.[0-9]`)
+	safariMajorRe     = regexp.MustCompile(`Version/([0-9]+)\.[0-9]`)
+	operaMajorRe      = regexp.MustCompile(`OPR/([0-9]+)\.[0-9]`)
+	samsungMajorRe    = regexp.MustCompile(`SamsungBrowser/([0-9]+)\.[0-9]`)
+	yandexMajorRe     = regexp.MustCompile(`YaBrowser/([0-9]+)\.[0-9]`)
 )
 
+var floorPatterns = map[string]*regexp.Regexp{
+	FloorChrome:     chromeMajorRe,
+	FloorFirefox:    firefoxMajorRe,
+	FloorEdge:       edgeMajorRe,
+	FloorChromeIOS:  chromeIOSMajorRe,
+	FloorFirefoxIOS: firefoxIOSMajorRe,
+	FloorEdgeIOS:    edgeIOSMajorRe,
+	FloorSamsung:    samsungMajorRe,
+}
+
+// IsFloorKey reports whether key is a known version floor key.
+func IsFloorKey(key string) bool {
+	_, ok := floorPatterns[key]
+	return ok
+}
+
+// Floor returns the configured floor for key, falling back to DefaultVersionFloors.
+func (f VersionFloors) Floor(key string) int {
+	if v, ok := f[key]; ok {
+		return v
+	}
+	return DefaultVersionFloors[key]
+}
+
+func majorVersion(re *regexp.Regexp, ua string) (int, bool) {
+	m := re.FindStringSubmatch(ua)
+	if len(m) < 2 {
+		return 0, false
+	}
+	v, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func isBelowFloor(ua, key string, floors VersionFloors) bool {
+	v, ok := majorVersion(floorPatterns[key], ua)
+	return ok && v < floors.Floor(key)
+}
+
 func IsOldBrowser(ua string) bool {
-	return IsOldChrome(ua) ||
-		IsOldFirefox(ua) ||
-		IsOldFirefoxIOS(ua) ||
-		IsOldChromeIOS(ua) ||
-		IsOldEdge(ua)
+	return IsOldBrowserWithFloors(ua, nil)
+}
+
+// IsOldBrowserWithFloors is IsOldBrowser with configurable version floors.
+func IsOldBrowserWithFloors(ua string, floors VersionFloors) bool {
+	// Samsung Internet ships an older Chromium than Chrome, so its own
+	// version is held against its own floor instead of the Chrome one.
+	if samsungMajorRe.MatchString(ua) {
+		return isBelowFloor(ua, FloorSamsung, floors)
+	}
+	return isBelowFloor(ua, FloorChrome, floors) ||
+		isBelowFloor(ua, FloorFirefox, floors) ||
+		isBelowFloor(ua, FloorFirefoxIOS, floors) ||
+		isBelowFloor(ua, FloorChromeIOS, floors) ||
+		isBelowFloor(ua, FloorEdgeIOS, floors) ||
+		isBelowFloor(ua, FloorEdge, floors)
 }
 func IsOldEdge(ua string) bool {
-	return oldEdgeRe.MatchString(ua)
+	return isBelowFloor(ua, FloorEdge, nil)
 }
 
 func IsOldChrome(ua string) bool {
-	return oldChromeRe.MatchString(ua)
+	return isBelowFloor(ua, FloorChrome, nil)
 }
 
 func IsOldFirefox(ua string) bool {
-	return oldFirefoxRe.MatchString(ua)
+	return isBelowFloor(ua, FloorFirefox, nil)
 }
 
 func IsOldFirefoxIOS(ua string) bool {
-	return oldFirefoxIOSRe.MatchString(ua)
+	return isBelowFloor(ua, FloorFirefoxIOS, nil)
 }
 
 func IsOldChromeIOS(ua string) bool {
-	return oldChromeIOSRe.MatchString(ua)
+	return isBelowFloor(ua, FloorChromeIOS, nil)
+}
+
+func IsOldEdgeIOS(ua string) bool {
+	return isBelowFloor(ua, FloorEdgeIOS, nil)
 }
@@ -0,0 +1,79 @@
+package useragent
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// WindowsVersion is a Sec-CH-UA-Platform-Version of Windows. Windows does not
+// report its own version there but that of the UniversalApiContract, which
+// is 1 to 10 on Windows 10 and 13 or higher on Windows 11. The reduced
+// User-Agent says Windows NT 10.0 on both.
+type WindowsVersion struct {
+	Major, Minor, Patch int
+
+	// Windows is the Windows version: 10 or 11.
+	Windows int
+
+	// Release names the Windows release, e.g. "24H2". It is empty for
+	// versions newer than the build table.
+	Release string
+}
+
+// windowsReleases maps each major platform version that Windows releases
+// report to the releases that report it.
+var windowsReleases = map[int]string{
+	1:  "1507",
+	2:  "1511",
+	3:  "1607",
+	4:  "1703",
+	5:  "1709",
+	6:  "1803",
+	7:  "1809",
+	8:  "1903, 1909",
+	10: "2004, 20H2, 21H1, 21H2, 22H2",
+	13: "Insider Preview",
+	14: "21H2",
+	15: "22H2, 23H2",
+	19: "24H2, 25H2",
+}
+
+// latestWindowsRelease is the highest major version in windowsReleases.
+var latestWindowsRelease = slices.Max(slices.Collect(maps.Keys(windowsReleases)))
+
+// ParseWindowsPlatformVersion parses the unquoted Sec-CH-UA-Platform-Version
+// of Windows. It fails for versions no Windows release reports and for
+// Windows 7, 8 and 8.1 (0.0.0), which Chromium no longer runs on. Versions
+// above the build table are accepted as newer Windows 11 releases.
+func ParseWindowsPlatformVersion(version string) (WindowsVersion, error) {
+	m := rePlatformVersion.FindStringSubmatch(version)
+	if m == nil {
+		return WindowsVersion{}, fmt.Errorf("%q is not a major.minor.patch version", version)
+	}
+	var v WindowsVersion
+	for i, part := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return WindowsVersion{}, fmt.Errorf("%q: %v", version, err)
+		}
+		*part = n
+	}
+	switch {
+	case v.Major == 0:
+		return v, fmt.Errorf("%s is Windows 7, 8 or 8.1, which Chromium does not support", version)
+	case v.Major <= 10:
+		v.Windows = 10
+	case v.Major < 13:
+		return v, fmt.Errorf("%s lies between Windows 10 (1-10) and Windows 11 (13 and up)", version)
+	default:
+		v.Windows = 11
+	}
+	release, ok := windowsReleases[v.Major]
+	if !ok && v.Major < latestWindowsRelease {
+		return v, fmt.Errorf("%s is no known Windows %d build", version, v.Windows)
+	}
+	v.Release = release
+	return v, nil
+}
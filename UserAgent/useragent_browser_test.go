@@ -0,0 +1,307 @@
+package useragent
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestDetectBrowser(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    BrowserKind
+	}{
+		{"chrome", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+		}, BrowserChrome},
+		{"chrome on android", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36",
+		}, BrowserChromeAndroid},
+		{"edge", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Edg/144.0.0.0",
+		}, BrowserEdge},
+		{"edge webview2", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Edg/144.0.0.0",
+			"Sec-Ch-Ua":  `"Microsoft Edge";v="144", "Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge WebView2";v="144"`,
+		}, BrowserEdgeWebView2},
+		{"edge on android", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36 EdgA/144.0.0.0",
+			"Sec-Ch-Ua":  `"Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge";v="144"`,
+		}, BrowserEdgeAndroid},
+		{"brave", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+			"Sec-Ch-Ua":  `"Brave";v="144", "Not(A:Brand";v="8", "Chromium";v="144"`,
+		}, BrowserBrave},
+		{"brave as part of another brand", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+			"Sec-Ch-Ua":  `"Not(A:Brand";v="8", "Chromium";v="144", "Brave New Browser";v="144"`,
+		}, BrowserChrome},
+		{"opera", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/140.0.0.0 Safari/537.36 OPR/124.0.0.0",
+		}, BrowserOpera},
+		{"samsung internet", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/28.0 Chrome/130.0.0.0 Mobile Safari/537.36",
+		}, BrowserSamsung},
+		{"yandex browser", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/140.0.0.0 YaBrowser/25.10.0.0 Safari/537.36",
+		}, BrowserYandex},
+		{"vivaldi with its token", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Vivaldi/7.6.3797.52",
+		}, BrowserVivaldi},
+		{"firefox", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0",
+			"Te":         "trailers",
+		}, BrowserFirefox},
+		{"firefox without te", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0",
+		}, BrowserFirefox},
+		{"firefox on android", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Android 10; Mobile; rv:146.0) Gecko/146.0 Firefox/146.0",
+			"Te":         "trailers",
+		}, BrowserFirefoxAndroid},
+		{"tor browser", map[string]string{
+			"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0",
+			"Accept-Language": "en-US,en;q=0.5",
+			"Te":              "trailers",
+		}, BrowserTor},
+		{"firefox 146 with english locale is not tor", map[string]string{
+			"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0",
+			"Accept-Language": "en-US,en;q=0.5",
+			"Te":              "trailers",
+		}, BrowserFirefox},
+		{"safari on mac", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.5 Safari/605.1.15",
+		}, BrowserSafari},
+		{"safari on iphone", map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 18_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
+		}, BrowserSafari},
+		{"edge on iphone is not safari", map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 26_1_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) EdgiOS/143.0.3650.130 Version/26.0 Mobile/15E148 Safari/604.1",
+		}, BrowserEdgeIOS},
+		{"chrome on ipad", map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPad; CPU OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/144.0.7559.85 Mobile/15E148 Safari/604.1",
+		}, BrowserChromeIOS},
+		{"firefox on iphone", map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/146.0 Mobile/15E148 Safari/605.1.15",
+		}, BrowserFirefoxIOS},
+		{"ios token on a desktop platform", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/144.0.7559.85 Mobile/15E148 Safari/604.1",
+		}, BrowserChromeIOS},
+		{"android webview", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A.240105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/144.0.7559.59 Mobile Safari/537.36",
+		}, BrowserAndroidWebView},
+		{"webview with a reduced user-agent", map[string]string{
+			"User-Agent":       "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36",
+			"X-Requested-With": "com.example.news",
+		}, BrowserAndroidWebView},
+		{"xmlhttprequest from chrome on android", map[string]string{
+			"User-Agent":       "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36",
+			"X-Requested-With": "XMLHttpRequest",
+		}, BrowserChromeAndroid},
+		{"facebook on android", map[string]string{
+			"User-Agent": "Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A.240105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/144.0.7559.59 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/540.0.0.45.68;]",
+		}, BrowserInApp},
+		{"instagram on iphone", map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 400.0.0.23.81 (iPhone15,2; iOS 18_7; en_US; en; scale=3.00; 1179x2556; 789012345)",
+		}, BrowserInApp},
+		{"curl", map[string]string{"User-Agent": "curl/8.5.0"}, BrowserUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			if got := DetectBrowser(h); got != tt.want {
+				t.Errorf("DetectBrowser() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirefoxVariant(t *testing.T) {
+	tests := []struct {
+		name           string
+		ua             string
+		acceptLanguage string
+		want           BrowserKind
+	}{
+		{"firefox", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0", "en-US,en;q=0.5", BrowserFirefox},
+		{"firefox on android", "Mozilla/5.0 (Android 10; Mobile; rv:146.0) Gecko/146.0 Firefox/146.0", "en-US,en;q=0.5", BrowserFirefoxAndroid},
+		{"tor browser 14.5", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0", "en-US,en;q=0.5", BrowserTor},
+		{"english firefox esr 140", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:140.0) Gecko/20100101 Firefox/140.0", "en-US,en;q=0.5", BrowserTor},
+		{"firefox esr with a real locale", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0", "nl,en-US;q=0.7,en;q=0.3", BrowserFirefox},
+		{"firefox esr on linux", "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", "en-US,en;q=0.5", BrowserFirefox},
+		{"rv and firefox versions differ", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/140.0", "en-US,en;q=0.5", BrowserFirefox},
+	}
+	p := DefaultProfiles()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("User-Agent", tt.ua)
+			h.Set("Accept-Language", tt.acceptLanguage)
+			if got := p.FirefoxVariant(h); got != tt.want {
+				t.Errorf("FirefoxVariant() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBrandList(t *testing.T) {
+	list, err := ParseBrandList(`"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.60", "Microsoft Edge";v="144.0.3719.82"`)
+	if err != nil || len(list) != 3 {
+		t.Fatalf("ParseBrandList() = %v, %v, want 3 entries", list, err)
+	}
+	if v, ok := list.Version(BrandEdge); !ok || v != "144.0.3719.82" {
+		t.Errorf("Version(%q) = %q, %v", BrandEdge, v, ok)
+	}
+	if got := list.Major(BrandChromium); got != "144" {
+		t.Errorf("Major(%q) = %q, want 144", BrandChromium, got)
+	}
+	if list.Has(BrandChrome) {
+		t.Errorf("Has(%q) = true for an Edge brand list", BrandChrome)
+	}
+	if got := list.Major(BrandChrome); got != "" {
+		t.Errorf("Major(%q) = %q, want empty", BrandChrome, got)
+	}
+}
+
+func TestValidateHeaderLengthEdge(t *testing.T) {
+	h := http.Header{}
+	h.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Edg/144.0.0.0")
+	h.Set("Sec-Ch-Ua", `"Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge";v="144"`)
+	result := ValidateHeaderLength(h)
+	if result.Browser != BrowserEdge || result.Min != 25 || result.Max != 30 || result.WithinSpec {
+		t.Errorf("ValidateHeaderLength() = %+v, want the edge range", result)
+	}
+
+	h.Set("Sec-Ch-Ua", `"Microsoft Edge";v="144", "Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge WebView2";v="144"`)
+	result = ValidateHeaderLength(h)
+	if result.Browser != BrowserEdgeWebView2 || result.Min != 14 || result.Max != 32 {
+		t.Errorf("ValidateHeaderLength() = %+v, want the edge_webview2 range", result)
+	}
+}
+
+func TestAppPackage(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{"com.instagram.android", "com.instagram.android", true},
+		{" com.example.news ", "com.example.news", true},
+		{"XMLHttpRequest", "", false},
+		{"com..example", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("X-Requested-With", tt.value)
+			got, ok := AppPackage(h)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("AppPackage(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBrandListOnlyBrands(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"Brave";v="144", "Not(A:Brand";v="8", "Chromium";v="144"`, true},
+		{`" Not A;Brand";v="99", "Chromium";v="131", "Brave";v="131"`, true},
+		{`"Brave";v="144", "Chromium";v="144"`, false},
+		{`"Brave";v="144", "Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144"`, false},
+		{`"Brave";v="144", "Not(A:Brand";v="8", "Not=A?Brand";v="24", "Chromium";v="144"`, false},
+	}
+	for _, tt := range tests {
+		list, _ := ParseBrandList(tt.header)
+		if got := list.OnlyBrands(BrandBrave, BrandChromium); got != tt.want {
+			t.Errorf("OnlyBrands(%s) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGreaseBrand(t *testing.T) {
+	tests := []struct {
+		major       int
+		brand       string
+		version     string
+		fullVersion string
+	}{
+		{130, "Not?A_Brand", "99", "99.0.0.0"},
+		{131, "Not_A Brand", "24", "24.0.0.0"},
+		{140, "Not=A?Brand", "24", "24.0.0.0"},
+		{144, "Not(A:Brand", "8", "8.0.0.0"},
+	}
+	for _, tt := range tests {
+		if got := GreaseBrand(tt.major, false); got != (BrandVersion{tt.brand, tt.version}) {
+			t.Errorf("GreaseBrand(%d, false) = %v, want %s %s", tt.major, got, tt.brand, tt.version)
+		}
+		if got := GreaseBrand(tt.major, true); got.Version != tt.fullVersion {
+			t.Errorf("GreaseBrand(%d, true) = %v, want version %s", tt.major, got, tt.fullVersion)
+		}
+		if !IsGreaseBrand(tt.brand) {
+			t.Errorf("IsGreaseBrand(%q) = false", tt.brand)
+		}
+	}
+}
+
+func TestGreasedBrandOrder(t *testing.T) {
+	tests := []struct {
+		major int
+		brand string
+		want  []string
+	}{
+		{144, "Google Chrome", []string{"Not(A:Brand", "Chromium", "Google Chrome"}},
+		{140, "Opera", []string{"Chromium", "Not=A?Brand", "Opera"}},
+		{130, "Samsung Internet", []string{"Chromium", "Samsung Internet", "Not?A_Brand"}},
+		{131, "Microsoft Edge", []string{"Microsoft Edge", "Chromium", "Not_A Brand"}},
+		{144, "", []string{"Not(A:Brand", "Chromium"}},
+		{131, "", []string{"Chromium", "Not_A Brand"}},
+	}
+	for _, tt := range tests {
+		if got := GreasedBrandOrder(tt.major, tt.brand); !slices.Equal(got, tt.want) {
+			t.Errorf("GreasedBrandOrder(%d, %q) = %q, want %q", tt.major, tt.brand, got, tt.want)
+		}
+	}
+}
+
+func TestFollowsGrease(t *testing.T) {
+	tests := []struct {
+		name   string
+		major  int
+		header string
+		full   bool
+		want   bool
+	}{
+		{"chrome 144", 144, `"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144"`, false, true},
+		{"chrome 144 full", 144, `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.60", "Google Chrome";v="144.0.7559.60"`, true, true},
+		{"opera on chromium 140", 140, `"Chromium";v="140", "Not=A?Brand";v="24", "Opera";v="124"`, false, true},
+		{"samsung on chromium 130", 130, `"Chromium";v="130", "Samsung Internet";v="28", "Not?A_Brand";v="99"`, false, true},
+		{"webview2 appends its brand", 131, `"Microsoft Edge";v="131", "Chromium";v="131", "Not_A Brand";v="24", "Microsoft Edge WebView2";v="131"`, false, true},
+		{"chromium without a brand", 144, `"Not(A:Brand";v="8", "Chromium";v="144"`, false, true},
+		{"grease of another version", 144, `"Not=A?Brand";v="24", "Chromium";v="144", "Google Chrome";v="144"`, false, false},
+		{"grease version of another version", 144, `"Not(A:Brand";v="99", "Chromium";v="144", "Google Chrome";v="144"`, false, false},
+		{"short grease version in full list", 144, `"Not(A:Brand";v="8", "Chromium";v="144.0.7559.60", "Google Chrome";v="144.0.7559.60"`, true, false},
+		{"sorted brands", 144, `"Chromium";v="144", "Google Chrome";v="144", "Not(A:Brand";v="8"`, false, false},
+		{"old grease", 144, `" Not A;Brand";v="99", "Chromium";v="144", "Google Chrome";v="144"`, false, false},
+		{"no grease", 144, `"Chromium";v="144", "Google Chrome";v="144"`, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := ParseBrandList(tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := list.FollowsGrease(tt.major, tt.full); got != tt.want {
+				t.Errorf("FollowsGrease(%d) = %v, want %v (expected %s)", tt.major, got, tt.want, list.ExpectedGrease(tt.major, tt.full))
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package useragent
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidatePlatformVersion(t *testing.T) {
+	const (
+		macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		androidUA = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36"
+		crosUA    = "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		linuxUA   = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+	)
+	tests := []struct {
+		name     string
+		ua       string
+		platform string
+		version  string
+		model    string // "-" leaves Sec-CH-UA-Model out
+		wantErr  bool
+	}{
+		{"macos sequoia", macUA, `"macOS"`, `"15.6.1"`, `""`, false},
+		{"macos tahoe", macUA, `"macOS"`, `"26.0.0"`, `""`, false},
+		{"macos newer than the data", macUA, `"macOS"`, `"27.1.0"`, `""`, false},
+		{"macos frozen version", macUA, `"macOS"`, `"10.15.7"`, `""`, true},
+		{"macos between releases", macUA, `"macOS"`, `"18.0.0"`, `""`, true},
+		{"macos unsupported", macUA, `"macOS"`, `"11.7.10"`, `""`, true},
+		{"macos with a windows user agent", windowsUA, `"macOS"`, `"15.6.1"`, `""`, true},
+		{"macos two-part version", macUA, `"macOS"`, `"15.6"`, `""`, true},
+		{"android with a model", androidUA, `"Android"`, `"14.0.0"`, `"Pixel 7"`, false},
+		{"android without a model", androidUA, `"Android"`, `"14.0.0"`, "-", true},
+		{"android frozen model", androidUA, `"Android"`, `"10.0.0"`, `"K"`, true},
+		{"android unsupported", androidUA, `"Android"`, `"9.0.0"`, `"Pixel 3"`, true},
+		{"chromeos", crosUA, `"Chrome OS"`, `"16328.65.0"`, `""`, false},
+		{"chromeos frozen version", crosUA, `"Chrome OS"`, `"14541.0.0"`, `""`, true},
+		{"chromeos without the cros token", linuxUA, `"Chrome OS"`, `"16328.65.0"`, `""`, true},
+		{"linux", linuxUA, `"Linux"`, `""`, `""`, false},
+		{"linux with a version", linuxUA, `"Linux"`, `"6.8.0"`, `""`, true},
+		{"windows has its own check", windowsUA, `"Windows"`, `"17.0.0"`, `""`, false},
+		{"no version", macUA, `"macOS"`, "", `""`, false},
+		{"malformed version", macUA, `"macOS"`, `10.15.7`, `""`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("User-Agent", tt.ua)
+			h.Set("Sec-CH-UA-Platform", tt.platform)
+			if tt.version != "" {
+				h.Set("Sec-CH-UA-Platform-Version", tt.version)
+			}
+			if tt.model != "-" {
+				h.Set("Sec-CH-UA-Model", tt.model)
+			}
+			err := DefaultProfiles().ValidatePlatformVersion(h)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlatformVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package useragent
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultProfiles(t *testing.T) {
+	p := DefaultProfiles()
+	if p.Revision == "" {
+		t.Errorf("default profiles have no revision")
+	}
+	for _, browser := range []BrowserKind{BrowserChrome, BrowserBrave, BrowserEdge, BrowserFirefox} {
+		bp, ok := p.Lookup(browser, 144)
+		if !ok {
+			t.Errorf("Lookup(%s, 144) found no profile", browser)
+			continue
+		}
+		if bp.Accept[DestinationDocument] == "" || bp.HeaderCount == nil {
+			t.Errorf("profile %s is incomplete: %+v", browser, bp)
+		}
+	}
+	if _, ok := p.Lookup(BrowserChrome, 120); ok {
+		t.Errorf("Lookup(chrome, 120) found a profile outside the version range")
+	}
+}
+
+func TestChromeAndroidUserAgent(t *testing.T) {
+	bp, ok := DefaultProfiles().Lookup(BrowserChromeAndroid, 144)
+	if !ok {
+		t.Fatal("Lookup(chrome_android, 144) found no profile")
+	}
+	tests := []struct {
+		name string
+		ua   string
+		want bool
+	}{
+		{"phone", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36", true},
+		{"tablet", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36", true},
+		{"token after safari", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36 DuckDuckGo/5", true},
+		{"unreduced platform", "Mozilla/5.0 (Linux; Android 14; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36", false},
+		{"unreduced version", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.7559.59 Mobile Safari/537.36", false},
+		{"glued to safari", "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36DuckDuckGo/5", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bp.MatchesUserAgent(tt.ua); got != tt.want {
+				t.Errorf("MatchesUserAgent(%q) = %v, want %v", tt.ua, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProfilesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"unknown field", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"], "colour": "red"}`},
+		{"wrong version", `{"version": 2, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"]}`},
+		{"no brands", `{"version": 1, "platforms": ["X11; Linux x86_64"]}`},
+		{"no platforms", `{"version": 1, "brands": ["Brave"]}`},
+		{"unknown browser", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"browsers": {"lynx": [{}]}}`},
+		{"inverted version range", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"browsers": {"chrome": [{"min_version": 150, "max_version": 140}]}}`},
+		{"unknown destination", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"browsers": {"chrome": [{"accept": {"script": "*/*"}}]}}`},
+		{"inverted header count", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"browsers": {"chrome": [{"header_count": {"min": 30, "max": 20}}]}}`},
+		{"unknown protocol", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"browsers": {"firefox": [{"protocol_headers": {"2.0": ["Te"]}}]}}`},
+		{"platform token of no platform", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"platform_tokens": {"Linux": ["X11; Linux riscv64"]}}`},
+		{"empty platform version with majors", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"platform_versions": {"Linux": {"empty": true, "majors": [6]}}}`},
+		{"invalid frozen platform version", `{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+			"platform_versions": {"macOS": {"frozen": ["10_15_7"]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProfiles([]byte(tt.data)); err == nil {
+				t.Errorf("ParseProfiles() expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	data := `{
+		"version": 1,
+		"revision": "test",
+		"brands": ["Google Chrome"],
+		"platforms": ["Windows NT 10.0; Win64; x64"],
+		"browsers": {
+			"chrome": [
+				{"max_version": 139, "header_count": {"min": 1, "max": 2}},
+				{"min_version": 140, "header_count": {"min": 3, "max": 40}}
+			]
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("LoadProfiles() error = %v", err)
+	}
+	if !p.IsBotFromSecChUa(`"Brave";v="144"`) {
+		t.Errorf("Brave should not be an allowed brand of the loaded profiles")
+	}
+	if p.ValidateReduction("Mozilla/5.0 (X11; Linux x86_64)") {
+		t.Errorf("Linux should not be a platform of the loaded profiles")
+	}
+
+	h := http.Header{}
+	h.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36")
+	result := ValidateHeaderLengthWithProfiles(h, p, nil)
+	if result.WithinSpec || result.Min != 3 || result.Max != 40 {
+		t.Errorf("ValidateHeaderLengthWithProfiles() = %+v, want the 140+ range", result)
+	}
+	if got := p.VersionRanges(BrowserChrome); got != "-139,140-" {
+		t.Errorf("VersionRanges() = %q", got)
+	}
+
+	if _, err := LoadProfiles(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("LoadProfiles() of a missing file expected an error")
+	}
+}
+
+func TestParseProfilesDefaultPlatformTokens(t *testing.T) {
+	p, err := ParseProfiles([]byte(`{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"]}`))
+	if err != nil {
+		t.Fatalf("ParseProfiles() error = %v", err)
+	}
+	if err := p.ValidatePlatformToken("Linux", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"); err == nil {
+		t.Errorf("ValidatePlatformToken() accepted a Windows User-Agent for Linux without platform_tokens")
+	}
+}
+
+func TestParseProfilesReportsFirstKeyInOrder(t *testing.T) {
+	data := []byte(`{"version": 1, "brands": ["Brave"], "platforms": ["X11; Linux x86_64"],
+		"browsers": {
+			"firefox": [{"min_version": 150, "max_version": 140}],
+			"chrome": [{"min_version": 150, "max_version": 140}],
+			"safari": [{"min_version": 150, "max_version": 140}]
+		}}`)
+	for range 20 {
+		_, err := ParseProfiles(data)
+		if err == nil || !strings.HasPrefix(err.Error(), "browser chrome,") {
+			t.Fatalf("ParseProfiles() error = %v, want the error of chrome", err)
+		}
+	}
+}
@@ -22,6 +22,16 @@ func TestValidateReduction(t *testing.T) {
 			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148",
 			want: false,
 		},
+		{
+			name: "iPhone Safari (should return true)",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 18_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
+			want: true,
+		},
+		{
+			name: "iPad Safari (should return true)",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			want: true,
+		},
 		{
 			name: "Mac reduced UA (example)",
 			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
@@ -58,8 +68,13 @@ func TestValidateReduction(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "Non‑reduced Iphone firefox IOS ",
+			name: "iPhone with FxiOS carries the real iOS version (should return true)",
 			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/118.0 Mobile/15E148 Safari/605.1.15",
+			want: true,
+		},
+		{
+			name: "FxiOS with a desktop platform (should return false)",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 13_5_1) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/146.0 Mobile/15E148 Safari/605.1.15",
 			want: false,
 		},
 		{
@@ -93,3 +108,40 @@ func TestValidateReduction(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePlatformToken(t *testing.T) {
+	const (
+		windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		armUA     = "Mozilla/5.0 (X11; Linux aarch64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		crosUA    = "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		webViewUA = "Mozilla/5.0 (Linux; Android 14; Pixel 7; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/144.0.0.0 Mobile Safari/537.36"
+	)
+	tests := []struct {
+		platform string
+		ua       string
+		wantErr  bool
+	}{
+		{"Windows", windowsUA, false},
+		{"macOS", macUA, false},
+		{"macOS", windowsUA, true},
+		{"Windows", macUA, true},
+		{"Linux", armUA, false},
+		{"Linux", crosUA, true},
+		{"Chrome OS", crosUA, false},
+		{"Chromium OS", crosUA, false},
+		{"Android", webViewUA, false},
+		{"Android", windowsUA, true},
+		{"Fuchsia", windowsUA, true},
+		{"Unknown", armUA, false},
+		{"Haiku", windowsUA, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			err := DefaultProfiles().ValidatePlatformToken(tt.platform, tt.ua)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlatformToken(%q) error = %v, wantErr %v", tt.platform, err, tt.wantErr)
+			}
+		})
+	}
+}
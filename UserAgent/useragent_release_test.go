@@ -0,0 +1,117 @@
+package useragent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReleasesLatest(t *testing.T) {
+	rs := DefaultReleases()
+	tests := []struct {
+		key  string
+		now  string
+		want int
+	}{
+		{FloorChrome, "2026-10-16", 153},
+		{FloorChrome, "2026-10-27", 154},
+		{FloorChrome, "2030-01-01", 155},
+		{FloorEdge, "2026-10-16", 153},
+		{FloorFirefox, "2026-10-16", 156},
+		{FloorChromeIOS, "2026-10-16", 153},
+		{FloorSamsung, "2026-10-16", 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.now, func(t *testing.T) {
+			now, _ := time.Parse(time.DateOnly, tt.now)
+			if got, ok := rs.Latest(tt.key, now); !ok || got != tt.want {
+				t.Errorf("Latest(%q, %s) = %d, %v, want %d", tt.key, tt.now, got, ok, tt.want)
+			}
+		})
+	}
+	if _, ok := rs.Latest(FloorChrome, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("Latest() before the first release should fail")
+	}
+}
+
+func TestReleasesProjected(t *testing.T) {
+	rs := DefaultReleases()
+	tests := []struct {
+		key  string
+		now  string
+		want int
+	}{
+		{FloorChrome, "2026-10-16", 153},
+		{FloorChrome, "2026-11-24", 155},
+		{FloorChrome, "2026-12-31", 156},
+		{FloorChrome, "2028-01-01", 168},
+		{FloorFirefox, "2028-01-01", 172},
+		{FloorChromeIOS, "2028-01-01", 168},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.now, func(t *testing.T) {
+			now, _ := time.Parse(time.DateOnly, tt.now)
+			if got, ok := rs.Projected(tt.key, now); !ok || got != tt.want {
+				t.Errorf("Projected(%q, %s) = %d, %v, want %d", tt.key, tt.now, got, ok, tt.want)
+			}
+		})
+	}
+	if _, ok := rs.Projected(FloorChrome, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Errorf("Projected() before the first release should fail")
+	}
+}
+
+func TestReleasesSupersededAt(t *testing.T) {
+	rs := DefaultReleases()
+	at, ok := rs.SupersededAt(FloorChrome, 144)
+	if want := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC); !ok || !at.Equal(want) {
+		t.Errorf("SupersededAt(chrome, 144) = %v, %v, want %v", at, ok, want)
+	}
+	if _, ok := rs.SupersededAt(FloorChrome, 155); ok {
+		t.Errorf("SupersededAt() of the last known release should fail")
+	}
+}
+
+func TestFloorKey(t *testing.T) {
+	tests := []struct {
+		ua      string
+		key     string
+		version int
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36", FloorChrome, 144},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Edg/145.0.0.0", FloorEdge, 145},
+		{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/28.0 Chrome/130.0.0.0 Mobile Safari/537.36", FloorSamsung, 28},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 18_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/144.0.7559.85 Mobile/15E148 Safari/604.1", FloorChromeIOS, 144},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0", FloorFirefox, 146},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			key, version, ok := FloorKey(tt.ua)
+			if !ok || key != tt.key || version != tt.version {
+				t.Errorf("FloorKey() = %q, %d, %v, want %q, %d", key, version, ok, tt.key, tt.version)
+			}
+		})
+	}
+	if _, _, ok := FloorKey("curl/8.5.0"); ok {
+		t.Errorf("FloorKey(curl) should fail")
+	}
+}
+
+func TestParseReleasesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unsupported version", `{"version": 2, "browsers": {}}`},
+		{"unknown field", `{"version": 1, "browsers": {}, "extra": true}`},
+		{"unknown browser", `{"version": 1, "browsers": {"lynx": {"2": "2025-01-01"}}}`},
+		{"invalid major", `{"version": 1, "browsers": {"chrome": {"x": "2025-01-01"}}}`},
+		{"invalid date", `{"version": 1, "browsers": {"chrome": {"140": "2 Sep 2025"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseReleases([]byte(tt.data)); err == nil {
+				t.Errorf("ParseReleases() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package useragent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  []Item
+	}{
+		{"empty", "", nil},
+		{"strings with parameters", `"Chromium";v="144", "Not(A:Brand";v="8"`, []Item{
+			{Value: "Chromium", Params: Params{{Key: "v", Value: "144"}}},
+			{Value: "Not(A:Brand", Params: Params{{Key: "v", Value: "8"}}},
+		}},
+		{"bare items", `?1, 42, -1.5, tok/en, :AQI=:, "a\"b"`, []Item{
+			{Value: true}, {Value: int64(42)}, {Value: -1.5}, {Value: Token("tok/en")}, {Value: []byte{1, 2}}, {Value: `a"b`},
+		}},
+		{"inner list", `("a" "b");q=1, c`, []Item{
+			{Value: []Item{{Value: "a"}, {Value: "b"}}, Params: Params{{Key: "q", Value: int64(1)}}},
+			{Value: Token("c")},
+		}},
+		{"boolean parameter", `a;x;y=?0`, []Item{
+			{Value: Token("a"), Params: Params{{Key: "x", Value: true}, {Key: "y", Value: false}}},
+		}},
+		{"repeated parameter", `a;v=1;v=2`, []Item{
+			{Value: Token("a"), Params: Params{{Key: "v", Value: int64(2)}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseList(tt.field)
+			if err != nil {
+				t.Fatalf("ParseList(%q) error = %v", tt.field, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseList(%q) = %#v, want %#v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListErrors(t *testing.T) {
+	for _, field := range []string{
+		`"a",`,
+		`"a" "b"`,
+		`"a" ;v="1"`,
+		`"a";V="1"`,
+		`"unterminated`,
+		`"bad \x escape"`,
+		"\"tést\"",
+		`?2`,
+		`1.`,
+		`1.2345`,
+		`1234567890123456`,
+		`:not base64:`,
+		`("a"`,
+		`'a'`,
+	} {
+		if got, err := ParseList(field); err == nil {
+			t.Errorf("ParseList(%q) = %v, want an error", field, got)
+		}
+	}
+}
+
+func TestParseItem(t *testing.T) {
+	if got, err := ParseItem(` "Windows"`); err != nil || got.Value != "Windows" {
+		t.Errorf("ParseItem() = %v, %v, want Windows", got, err)
+	}
+	for _, field := range []string{"", "Windows 10", `"a", "b"`, `"a"x`} {
+		if got, err := ParseItem(field); err == nil {
+			t.Errorf("ParseItem(%q) = %v, want an error", field, got)
+		}
+	}
+}
+
+func TestValidateClientHint(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"Sec-CH-UA", `"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144"`, false},
+		{"Sec-CH-UA", `"Chromium";v=144`, true},
+		{"Sec-CH-UA", `"Chromium"`, true},
+		{"Sec-CH-UA", `Chromium;v="144"`, true},
+		{"Sec-CH-UA", `"Chromium";v="144",`, true},
+		{"Sec-CH-UA-Platform", `"Windows"`, false},
+		{"Sec-CH-UA-Platform", `Windows`, true},
+		{"Sec-CH-UA-Platform", `'Windows'`, true},
+		{"Sec-CH-UA-Model", `""`, false},
+		{"Sec-CH-UA-Mobile", `?0`, false},
+		{"Sec-CH-UA-Mobile", `0`, true},
+		{"Sec-CH-UA-Mobile", `"?1"`, true},
+		{"Sec-CH-UA-Form-Factors", `"Desktop", "XR"`, false},
+		{"Sec-CH-UA-Form-Factors", `Desktop`, true},
+		{"Sec-CH-Prefers-Color-Scheme", `light`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.value, func(t *testing.T) {
+			if err := ValidateClientHint(tt.name, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateClientHint(%q, %q) error = %v, wantErr %v", tt.name, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package useragent
+
+import "testing"
+
+func TestParseWindowsPlatformVersion(t *testing.T) {
+	tests := []struct {
+		version     string
+		wantWindows int
+		wantRelease string
+		wantErr     bool
+	}{
+		{"1.0.0", 10, "1507", false},
+		{"10.0.0", 10, "2004, 20H2, 21H1, 21H2, 22H2", false},
+		{"14.0.0", 11, "21H2", false},
+		{"19.0.0", 11, "24H2, 25H2", false},
+		{"25.0.0", 11, "", false},
+		{"0.0.0", 0, "", true},
+		{"9.0.0", 10, "", true},
+		{"12.0.0", 0, "", true},
+		{"17.0.0", 11, "", true},
+		{"10.0", 0, "", true},
+		{"10.0.19045", 10, "2004, 20H2, 21H1, 21H2, 22H2", false},
+		{"v19.0.0", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, err := ParseWindowsPlatformVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindowsPlatformVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+			if err == nil && (v.Windows != tt.wantWindows || v.Release != tt.wantRelease) {
+				t.Errorf("ParseWindowsPlatformVersion(%q) = %+v, want Windows %d %q", tt.version, v, tt.wantWindows, tt.wantRelease)
+			}
+		})
+	}
+}
@@ -117,3 +117,28 @@ func TestIsOldBrowser(t *testing.T) {
 		})
 	}
 }
+
+func TestIsOldBrowserWithFloors(t *testing.T) {
+	floors := VersionFloors{FloorFirefox: 128, FloorChrome: 145}
+	tests := []struct {
+		name string
+		ua   string
+		want bool
+	}{
+		{"Firefox ESR above lowered floor", "Mozilla/5.0 Firefox/128.0", false},
+		{"Firefox below lowered floor", "Mozilla/5.0 Firefox/115.0", true},
+		{"Chrome below raised floor", "Mozilla/5.0 Chrome/142.0.0.0 Safari/537.36", true},
+		{"Edge uses default floor", "Mozilla/5.0 Edg/139.0.0.0", true},
+		{"Samsung Internet ignores the chrome floor", "Mozilla/5.0 SamsungBrowser/28.0 Chrome/130.0.0.0 Mobile Safari/537.36", false},
+		{"Samsung Internet below its own floor", "Mozilla/5.0 SamsungBrowser/26.0 Chrome/122.0.0.0 Mobile Safari/537.36", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IsOldBrowserWithFloors(tt.ua, floors)
+			if got != tt.want {
+				t.Errorf("IsOldBrowserWithFloors(%q) = %v, want %v", tt.ua, got, tt.want)
+			}
+		})
+	}
+}
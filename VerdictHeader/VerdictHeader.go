@@ -0,0 +1,153 @@
+// Package verdictheader signs and verifies the verdict header the
+// headerchecker handler adds to requests it forwards, so services behind
+// Caddy can trust the verdict without depending on Caddy.
+package verdictheader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHeader is the request header that carries the signed verdict.
+const DefaultHeader = "X-Headerchecker-Verdict"
+
+// version is the format version of the header value.
+const version = "1"
+
+var (
+	ErrMissing      = errors.New("verdict header missing")
+	ErrMalformed    = errors.New("verdict header malformed")
+	ErrUnknownKey   = errors.New("verdict header signed with unknown key")
+	ErrBadSignature = errors.New("verdict header signature invalid")
+	ErrExpired      = errors.New("verdict header expired")
+)
+
+// Verdict is the signed content of the header.
+type Verdict struct {
+	KeyID     string
+	Timestamp time.Time
+	Score     int
+	Class     string
+	Reasons   []string
+}
+
+// payload returns the signed part of the header value.
+func (v Verdict) payload() string {
+	return strings.Join([]string{
+		"v=" + version,
+		"kid=" + v.KeyID,
+		"ts=" + strconv.FormatInt(v.Timestamp.Unix(), 10),
+		"score=" + strconv.Itoa(v.Score),
+		"class=" + v.Class,
+		"reasons=" + strings.Join(v.Reasons, ","),
+	}, ";")
+}
+
+func signature(payload string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns the header value for v signed with key.
+func Sign(v Verdict, key []byte) string {
+	payload := v.payload()
+	return payload + ";sig=" + signature(payload, key)
+}
+
+// Parse reads a header value without verifying its signature.
+func Parse(value string) (Verdict, string, error) {
+	var v Verdict
+	fields := strings.Split(value, ";")
+	if len(fields) != 7 {
+		return v, "", ErrMalformed
+	}
+	want := []string{"v", "kid", "ts", "score", "class", "reasons", "sig"}
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		k, val, ok := strings.Cut(field, "=")
+		if !ok || k != want[i] {
+			return v, "", ErrMalformed
+		}
+		values[i] = val
+	}
+	if values[0] != version {
+		return v, "", fmt.Errorf("%w: unsupported version %q", ErrMalformed, values[0])
+	}
+	ts, err := strconv.ParseInt(values[2], 10, 64)
+	if err != nil {
+		return v, "", fmt.Errorf("%w: timestamp: %v", ErrMalformed, err)
+	}
+	score, err := strconv.Atoi(values[3])
+	if err != nil {
+		return v, "", fmt.Errorf("%w: score: %v", ErrMalformed, err)
+	}
+	v.KeyID = values[1]
+	v.Timestamp = time.Unix(ts, 0)
+	v.Score = score
+	v.Class = values[4]
+	if values[5] != "" {
+		v.Reasons = strings.Split(values[5], ",")
+	}
+	return v, values[6], nil
+}
+
+// Verifier checks signed verdict headers.
+type Verifier struct {
+	// Keys maps key IDs to HMAC keys.
+	Keys map[string][]byte
+
+	// MaxAge is how old a verdict may be. Zero disables the check.
+	MaxAge time.Duration
+
+	// Header is the header to read. Default: DefaultHeader.
+	Header string
+
+	// Now returns the current time. Default: time.Now.
+	Now func() time.Time
+}
+
+// Verify checks the signature and age of a header value and returns its verdict.
+func (vr Verifier) Verify(value string) (Verdict, error) {
+	v, sig, err := Parse(value)
+	if err != nil {
+		return Verdict{}, err
+	}
+	key, ok := vr.Keys[v.KeyID]
+	if !ok {
+		return Verdict{}, ErrUnknownKey
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(v.payload(), key))) {
+		return Verdict{}, ErrBadSignature
+	}
+	if vr.MaxAge > 0 {
+		now := time.Now
+		if vr.Now != nil {
+			now = vr.Now
+		}
+		if now().Sub(v.Timestamp) > vr.MaxAge {
+			return Verdict{}, ErrExpired
+		}
+	}
+	return v, nil
+}
+
+// VerifyRequest verifies the verdict header of r.
+func (vr Verifier) VerifyRequest(r *http.Request) (Verdict, error) {
+	header := vr.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	value := r.Header.Get(header)
+	if value == "" {
+		return Verdict{}, ErrMissing
+	}
+	return vr.Verify(value)
+}
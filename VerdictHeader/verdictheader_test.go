@@ -0,0 +1,70 @@
+package verdictheader
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1760000000, 0)
+	v := Verdict{KeyID: "k1", Timestamp: now, Score: 60, Class: "bot", Reasons: []string{"accept_charset_present", "ua_not_reduced"}}
+	value := Sign(v, key)
+
+	verifier := Verifier{
+		Keys:   map[string][]byte{"k1": key},
+		MaxAge: time.Minute,
+		Now:    func() time.Time { return now.Add(30 * time.Second) },
+	}
+	got, err := verifier.Verify(value)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("Verify() = %+v, want %+v", got, v)
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(DefaultHeader, value)
+	if _, err := verifier.VerifyRequest(r); err != nil {
+		t.Errorf("VerifyRequest() error = %v", err)
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1760000000, 0)
+	value := Sign(Verdict{KeyID: "k1", Timestamp: now, Score: 0, Class: "human"}, key)
+
+	verifier := Verifier{
+		Keys:   map[string][]byte{"k1": key},
+		MaxAge: time.Minute,
+		Now:    func() time.Time { return now },
+	}
+	tests := []struct {
+		name     string
+		verifier Verifier
+		value    string
+		want     error
+	}{
+		{"malformed", verifier, "score=0", ErrMalformed},
+		{"tampered score", verifier, "v=1;kid=k1;ts=1760000000;score=99" + value[len("v=1;kid=k1;ts=1760000000;score=0"):], ErrBadSignature},
+		{"unknown key", Verifier{Keys: map[string][]byte{"k2": key}}, value, ErrUnknownKey},
+		{"expired", Verifier{Keys: verifier.Keys, MaxAge: time.Minute, Now: func() time.Time { return now.Add(2 * time.Minute) }}, value, ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.verifier.Verify(tt.value); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if _, err := verifier.VerifyRequest(r); !errors.Is(err, ErrMissing) {
+		t.Errorf("VerifyRequest() error = %v, want %v", err, ErrMissing)
+	}
+}
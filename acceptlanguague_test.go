@@ -1,6 +1,7 @@
 package CaddyHeaderVerification
 
 import (
+	"net/http/httptest"
 	"testing"
 
 	"go.uber.org/zap"
@@ -14,25 +15,32 @@ func TestValidateAcceptLanguage_Empty(t *testing.T) {
 	tests := []struct {
 		name           string
 		acceptLanguage string
-		want           bool
+		want           string
 	}{
 		{
 			name:           "empty string",
 			acceptLanguage: "",
-			want:           true, // should log and return true
+			want:           ReasonAcceptLanguageMissing,
 		},
 		{
 			name:           "only spaces",
 			acceptLanguage: "   ",
-			want:           true, // should log and return true
+			want:           ReasonAcceptLanguageMissing,
+		},
+		{
+			name:           "multiple languages but contains a space",
+			acceptLanguage: "en-US, en; q=0.5",
+			want:           ReasonAcceptLanguageWhitespace,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := h.validateAcceptLanguage(tt.acceptLanguage)
-			if got != tt.want {
-				t.Fatalf("validateAcceptLanguage(%q) = %v, want %v",
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			got := h.checkAcceptLanguage(req)
+			if got == nil || got.Reason != tt.want || got.Check != CheckAcceptLanguage {
+				t.Fatalf("checkAcceptLanguage(%q) = %+v, want reason %q",
 					tt.acceptLanguage, got, tt.want)
 			}
 		})
@@ -55,17 +63,14 @@ func TestValidateAcceptLanguage_NonEmpty(t *testing.T) {
 			name:           "multiple languages",
 			acceptLanguage: "nl,nl-NL;q=0.9,en-US;q=0.8,en;q=0.7",
 		},
-		{
-			name:           "multiple languages but contains a space",
-			acceptLanguage: "en-US, en; q=0.5",
-		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := h.validateAcceptLanguage(tt.acceptLanguage)
-			if got {
-				t.Fatalf("validateAcceptLanguage(%q) = true, want false", tt.acceptLanguage)
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			if got := h.checkAcceptLanguage(req); got != nil {
+				t.Fatalf("checkAcceptLanguage(%q) = %+v, want nil", tt.acceptLanguage, got)
 			}
 		})
 	}
@@ -0,0 +1,123 @@
+package CaddyHeaderVerification
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func botRequest() *http.Request {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	req.Header.Set("Accept", "*/*")
+	return req
+}
+
+func TestActionFor(t *testing.T) {
+	h := HeaderChecker{
+		Actions: map[string]*Action{
+			string(ClassBot): {Type: ActionReject},
+		},
+		Checks: map[string]*CheckConfig{
+			CheckSecChUaBrand:   {Action: &Action{Type: ActionAbort}},
+			CheckAcceptLanguage: {Action: &Action{Type: ActionLog}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		class  VerdictClass
+		failed []string
+		want   string
+	}{
+		{"default is tag", ClassHuman, nil, ActionTag},
+		{"class action", ClassBot, nil, ActionReject},
+		{"more severe check action wins", ClassBot, []string{CheckSecChUaBrand}, ActionAbort},
+		{"less severe check action is ignored", ClassBot, []string{CheckAcceptLanguage}, ActionReject},
+		{"check action on human class", ClassHuman, []string{CheckSecChUaBrand}, ActionAbort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Verdict{Class: tt.class}
+			for _, id := range tt.failed {
+				v.Findings = append(v.Findings, Finding{Check: id})
+			}
+			if got := h.actionFor(v); got.Type != tt.want {
+				t.Errorf("actionFor() = %q, want %q", got.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTPActions(t *testing.T) {
+	nextCalled := false
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		nextCalled = true
+		return nil
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		nextCalled = false
+		h := HeaderChecker{Actions: map[string]*Action{
+			string(ClassBot): {Type: ActionReject, StatusCode: http.StatusTeapot, Body: "go away"},
+		}}
+		rec := httptest.NewRecorder()
+		if err := h.ServeHTTP(rec, botRequest(), next); err != nil {
+			t.Fatalf("ServeHTTP() error = %v", err)
+		}
+		if nextCalled {
+			t.Error("next handler was called")
+		}
+		if rec.Code != http.StatusTeapot || rec.Body.String() != "go away" {
+			t.Errorf("response = %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		nextCalled = false
+		h := HeaderChecker{Actions: map[string]*Action{
+			string(ClassBot): {Type: ActionError, StatusCode: http.StatusUnauthorized},
+		}}
+		err := h.ServeHTTP(httptest.NewRecorder(), botRequest(), next)
+		var handlerErr caddyhttp.HandlerError
+		if !errors.As(err, &handlerErr) || handlerErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("ServeHTTP() error = %v, want HandlerError with status 401", err)
+		}
+		if nextCalled {
+			t.Error("next handler was called")
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		h := HeaderChecker{Actions: map[string]*Action{
+			string(ClassBot): {Type: ActionAbort},
+		}}
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("recover() = %v, want http.ErrAbortHandler", rec)
+			}
+		}()
+		_ = h.ServeHTTP(httptest.NewRecorder(), botRequest(), next)
+	})
+
+	t.Run("log only", func(t *testing.T) {
+		nextCalled = false
+		h := HeaderChecker{Actions: map[string]*Action{
+			string(ClassBot): {Type: ActionLog},
+		}}
+		rec := httptest.NewRecorder()
+		if err := h.ServeHTTP(rec, botRequest(), next); err != nil {
+			t.Fatalf("ServeHTTP() error = %v", err)
+		}
+		if !nextCalled {
+			t.Error("next handler was not called")
+		}
+		if got := rec.Header().Get("SecureHeader"); got != "" {
+			t.Errorf("SecureHeader = %q, want it unset", got)
+		}
+	})
+}
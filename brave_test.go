@@ -0,0 +1,75 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+// braveHeaders are the headers Brave 144 on Windows sends for a top-level
+// navigation. Brave sends Sec-GPC, leaves out the high-entropy hints it is
+// not asked for and reports its builds as major.0.0.0.
+func braveHeaders() map[string]string {
+	headers := chromeHeaders()
+	headers["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8"
+	headers["Sec-Ch-Ua"] = `"Not(A:Brand";v="8", "Chromium";v="144", "Brave";v="144"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.0.0", "Brave";v="144.0.0.0"`
+	headers["Sec-Gpc"] = "1"
+	for _, name := range []string{"Cache-Control", "Sec-Ch-Device-Memory", "Sec-Ch-Dpr", "Sec-Ch-Ua-Form-Factors",
+		"Sec-Ch-Ua-Full-Version", "Sec-Ch-Ua-Wow64", "Sec-Ch-Viewport-Height", "Sec-Ch-Viewport-Width"} {
+		delete(headers, name)
+	}
+	return headers
+}
+
+func TestCheckRequiredHeadersBrave(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"brave", braveHeaders(), ""},
+		{"without sec-gpc", withHeaders(braveHeaders(), map[string]string{"Sec-Gpc": ""}), ReasonRequiredHeaderMissing},
+		{"sec-gpc off", withHeaders(braveHeaders(), map[string]string{"Sec-Gpc": "0"}), ReasonHeaderValueMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkRequiredHeaders(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkRequiredHeaders() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckClientHintVersionsBrave(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"brave", braveHeaders(), ""},
+		{"brave brand added to chrome", withHeaders(chromeHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144", "Brave";v="144"`,
+		}), ReasonClientHintBrandMismatch},
+		{"brave brand behind chromium", withHeaders(braveHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Not(A:Brand";v="8", "Chromium";v="144", "Brave";v="143"`,
+		}), ReasonClientHintVersionMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkClientHintVersions(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkClientHintVersions() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
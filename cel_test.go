@@ -0,0 +1,86 @@
+package CaddyHeaderVerification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func TestCELFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		headers func() map[string]string
+		want    bool
+	}{
+		{"score of curl", "bot_score() >= 60", func() map[string]string {
+			return map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}
+		}, true},
+		{"score of chrome", "bot_score() == 0 && bot_class() == 'human'", chromeHeaders, true},
+		{"reasons", "'accept_wildcard_only' in bot_reasons()", func() map[string]string {
+			return map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}
+		}, true},
+		{"checks", "'accept_charset' in bot_checks()", firefoxHeaders, false},
+		{"browser", "bot_browser() == 'firefox'", firefoxHeaders, true},
+		{"combined with a path matcher", "bot_score() > 60 && path('/api/*')", func() map[string]string {
+			return map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+			defer cancel()
+
+			m := &caddyhttp.MatchExpression{Expr: tt.expr}
+			if err := m.Provision(ctx); err != nil {
+				t.Fatalf("Provision(%q) error = %v", tt.expr, err)
+			}
+			req, _ := withCaddyContext(newRequest("/", tt.headers()))
+			publishVerdict(req, HeaderChecker{}.Evaluate(req))
+			got, err := m.MatchWithError(req)
+			if err != nil {
+				t.Fatalf("MatchWithError() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCELUsesStoredVerdict(t *testing.T) {
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+
+	m := &caddyhttp.MatchExpression{Expr: "bot_score() == 42"}
+	if err := m.Provision(ctx); err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	req, _ := withCaddyContext(newRequest("/", chromeHeaders()))
+	publishVerdict(req, Verdict{Score: 42, Class: ClassSuspicious})
+
+	if got, err := m.MatchWithError(req); err != nil || !got {
+		t.Errorf("MatchWithError() = %v, %v, want the stored score to be used", got, err)
+	}
+}
+
+func TestCELWithoutStoredVerdict(t *testing.T) {
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+
+	m := &caddyhttp.MatchExpression{Expr: "bot_score() == 0 && bot_class() == '' && bot_browser() == '' && size(bot_reasons()) == 0 && size(bot_checks()) == 0"}
+	if err := m.Provision(ctx); err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+	req, _ := withCaddyContext(newRequest("/", map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}))
+
+	if got, err := m.MatchWithError(req); err != nil || !got {
+		t.Errorf("MatchWithError() = %v, %v, want an empty verdict", got, err)
+	}
+	if _, ok := VerdictFromRequest(req); ok {
+		t.Errorf("the CEL functions stored a verdict of their own")
+	}
+}
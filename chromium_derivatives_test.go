@@ -0,0 +1,70 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+// operaHeaders are the headers Opera 124 (Chromium 140) on Windows sends for
+// a top-level navigation when all client hints are requested.
+func operaHeaders() map[string]string {
+	headers := chromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/140.0.0.0 Safari/537.36 OPR/124.0.0.0"
+	headers["Sec-Ch-Ua"] = `"Chromium";v="140", "Not=A?Brand";v="24", "Opera";v="124"`
+	headers["Sec-Ch-Ua-Full-Version"] = `"124.0.5705.42"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Chromium";v="140.0.7339.208", "Not=A?Brand";v="24.0.0.0", "Opera";v="124.0.5705.42"`
+	return headers
+}
+
+// samsungHeaders are the headers Samsung Internet 28 (Chromium 130) on an
+// Android phone sends for a top-level navigation.
+func samsungHeaders() map[string]string {
+	headers := androidChromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/28.0 Chrome/130.0.0.0 Mobile Safari/537.36"
+	headers["Sec-Ch-Ua"] = `"Chromium";v="130", "Samsung Internet";v="28", "Not?A_Brand";v="99"`
+	headers["Sec-Ch-Ua-Full-Version"] = `"28.0.1.59"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Chromium";v="130.0.6723.86", "Samsung Internet";v="28.0.1.59", "Not?A_Brand";v="99.0.0.0"`
+	headers["Sec-Ch-Ua-Model"] = `"SM-S918B"`
+	return headers
+}
+
+// yandexHeaders are the headers Yandex Browser 25.10 (Chromium 140) on
+// Windows sends for a top-level navigation.
+func yandexHeaders() map[string]string {
+	headers := chromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/140.0.0.0 YaBrowser/25.10.0.0 Safari/537.36"
+	headers["Sec-Ch-Ua"] = `"Chromium";v="140", "Not=A?Brand";v="24", "YaBrowser";v="25.10", "Yowser";v="2.5"`
+	headers["Sec-Ch-Ua-Full-Version"] = `"25.10.0.1020"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Chromium";v="140.0.7339.1020", "Not=A?Brand";v="24.0.0.0", "YaBrowser";v="25.10.0.1020", "Yowser";v="2.5"`
+	return headers
+}
+
+func TestCheckClientHintVersionsOpera(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"opera", operaHeaders(), ""},
+		{"opera without the opera brand", withHeaders(operaHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Chromium";v="140", "Not=A?Brand";v="24", "Google Chrome";v="140"`,
+		}), ReasonClientHintBrandMismatch},
+		{"chrome with the opera brand", withHeaders(chromeHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Not(A:Brand";v="8", "Chromium";v="144", "Opera";v="144"`,
+		}), ReasonClientHintBrandMismatch},
+		{"opera brand with the chromium version", withHeaders(operaHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Chromium";v="140", "Not=A?Brand";v="24", "Opera";v="140"`,
+		}), ReasonClientHintVersionMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkClientHintVersions(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkClientHintVersions() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+func TestCheckClientHintSyntax(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name  string
+		hint  string
+		value string
+		want  bool
+	}{
+		{"chrome", "Sec-Ch-Ua", chromeHeaders()["Sec-Ch-Ua"], true},
+		{"unquoted platform", "Sec-Ch-Ua-Platform", "Windows", false},
+		{"mobile as string", "Sec-Ch-Ua-Mobile", `"?0"`, false},
+		{"single-quoted model", "Sec-Ch-Ua-Model", `''`, false},
+		{"full version list without quotes", "Sec-Ch-Ua-Full-Version-List",
+			`"Not(A:Brand";v=8.0.0.0, "Chromium";v=144.0.7559.60, "Google Chrome";v=144.0.7559.60`, false},
+		{"brand list with trailing comma", "Sec-Ch-Ua",
+			`"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144",`, false},
+		{"space before parameter", "Sec-Ch-Ua",
+			`"Not(A:Brand" ;v="8", "Chromium";v="144", "Google Chrome";v="144"`, false},
+		{"optional whitespace", "Sec-Ch-Ua",
+			`"Not(A:Brand";v="8",  "Chromium";v="144",	"Google Chrome";v="144"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := withHeaders(chromeHeaders(), map[string]string{tt.hint: tt.value})
+			f := h.checkClientHintSyntax(newRequest("/", headers))
+			if got := f == nil; got != tt.want {
+				t.Errorf("checkClientHintSyntax() = %+v, want valid %v", f, tt.want)
+			}
+			if f != nil && f.Reason != ReasonClientHintMalformed {
+				t.Errorf("Reason = %q, want %q", f.Reason, ReasonClientHintMalformed)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+// edgeHeaders are the headers Edge 144 on Windows sends for a top-level
+// navigation when all client hints are requested. Edge reports its own build
+// next to the Chromium one.
+func edgeHeaders() map[string]string {
+	headers := chromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Edg/144.0.0.0"
+	headers["Sec-Ch-Ua"] = `"Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge";v="144"`
+	headers["Sec-Ch-Ua-Full-Version"] = `"144.0.3719.82"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.60", "Microsoft Edge";v="144.0.3719.82"`
+	return headers
+}
+
+// webView2Headers are the headers an Edge WebView2 144 control sends for a
+// top-level navigation.
+func webView2Headers() map[string]string {
+	headers := edgeHeaders()
+	headers["Sec-Ch-Ua"] = `"Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge";v="144", "Microsoft Edge WebView2";v="144"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.60", "Microsoft Edge";v="144.0.3719.82", "Microsoft Edge WebView2";v="144.0.3719.82"`
+	delete(headers, "Sec-Ch-Device-Memory")
+	delete(headers, "Sec-Ch-Viewport-Height")
+	delete(headers, "Sec-Ch-Viewport-Width")
+	return headers
+}
+
+// edgeAndroidHeaders are the headers Edge 144 on an Android phone sends for a
+// top-level navigation when all client hints are requested. Its User-Agent is
+// the one of Chrome for Android with an EdgA/ token appended.
+func edgeAndroidHeaders() map[string]string {
+	headers := androidChromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36 EdgA/144.0.0.0"
+	headers["Sec-Ch-Ua"] = `"Not(A:Brand";v="8", "Chromium";v="144", "Microsoft Edge";v="144"`
+	headers["Sec-Ch-Ua-Full-Version"] = `"144.0.3719.82"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.59", "Microsoft Edge";v="144.0.3719.82"`
+	return headers
+}
+
+func TestCheckClientHintVersionsEdge(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"edge", edgeHeaders(), ""},
+		{"edge webview2", webView2Headers(), ""},
+		{"edge on android", edgeAndroidHeaders(), ""},
+		{"chrome for android with the edge brand", withHeaders(edgeAndroidHeaders(), map[string]string{
+			"User-Agent": androidChromeHeaders()["User-Agent"],
+		}), ReasonClientHintBrandMismatch},
+		{"edge without the edge brand", withHeaders(edgeHeaders(), map[string]string{
+			"Sec-Ch-Ua": chromeHeaders()["Sec-Ch-Ua"],
+		}), ReasonClientHintBrandMismatch},
+		{"chrome with the edge brand", withHeaders(chromeHeaders(), map[string]string{
+			"Sec-Ch-Ua": edgeHeaders()["Sec-Ch-Ua"],
+		}), ReasonClientHintBrandMismatch},
+		{"edge token ahead of the chromium version", withHeaders(edgeHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36 Edg/145.0.0.0",
+		}), ReasonClientHintVersionMismatch},
+		{"edge build copied from chromium", withHeaders(edgeHeaders(), map[string]string{
+			"Sec-Ch-Ua-Full-Version":      `"144.0.7559.60"`,
+			"Sec-Ch-Ua-Full-Version-List": `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.60", "Microsoft Edge";v="144.0.7559.60"`,
+		}), ReasonClientHintBuildMismatch},
+		{"chrome full version off the chromium build", withHeaders(chromeHeaders(), map[string]string{
+			"Sec-Ch-Ua-Full-Version": `"144.0.3719.82"`,
+		}), ReasonClientHintBuildMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkClientHintVersions(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkClientHintVersions() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package CaddyHeaderVerification
+
+import (
+	"testing"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+)
+
+// torHeaders are the headers Tor Browser 14.5 (Firefox 128 ESR) sends for a
+// top-level navigation over HTTP/2.
+func torHeaders() map[string]string {
+	headers := firefoxHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	return headers
+}
+
+func TestCheckOldBrowserFirefox(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"firefox 146", firefoxHeaders(), ""},
+		{"firefox esr below the version floor", withHeaders(firefoxHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0",
+		}), ""},
+		{"firefox 135 below the version floor", withHeaders(firefoxHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:135.0) Gecko/20100101 Firefox/135.0",
+		}), ReasonBrowserTooOld},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkOldBrowser(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkOldBrowser() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestFirefoxProtocols(t *testing.T) {
+	tests := []struct {
+		name       string
+		protoMajor int
+		te         bool
+		wantReason string
+	}{
+		{"http/2", 2, true, ""},
+		{"http/2 without te", 2, false, ReasonRequiredHeaderMissing},
+		{"http/1.1 without te", 1, false, ""},
+		{"http/1.1 with te", 1, true, ""},
+		{"http/3 without te", 3, false, ""},
+	}
+	var h HeaderChecker
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := firefoxHeaders()
+			if !tt.te {
+				delete(headers, "Te")
+			}
+			r := newRequest("/", headers)
+			r.ProtoMajor = tt.protoMajor
+			if got := useragent.DetectBrowser(r.Header); got != useragent.BrowserFirefox {
+				t.Errorf("DetectBrowser() = %q, want %q", got, useragent.BrowserFirefox)
+			}
+			f := h.checkRequiredHeaders(r)
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkRequiredHeaders() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestTorPolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantClass VerdictClass
+	}{
+		{"", ClassHuman},
+		{TorPolicyAllow, ClassHuman},
+		{TorPolicyChallenge, ClassSuspicious},
+		{TorPolicyBlock, ClassBot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			h := HeaderChecker{TorPolicy: tt.policy}
+			v := h.Evaluate(newRequest("/", torHeaders()))
+			if v.Browser != useragent.BrowserTor {
+				t.Errorf("Browser = %q, want %q", v.Browser, useragent.BrowserTor)
+			}
+			if v.Class != tt.wantClass {
+				t.Errorf("Class = %q, want %q (findings %+v)", v.Class, tt.wantClass, v.Findings)
+			}
+
+			// the same browser with a real locale is an ordinary Firefox ESR
+			headers := torHeaders()
+			headers["Accept-Language"] = "nl,en-US;q=0.7,en;q=0.3"
+			if v := h.Evaluate(newRequest("/", headers)); v.Class != ClassHuman || v.Browser != useragent.BrowserFirefox {
+				t.Errorf("Firefox ESR verdict = %q %q, want human firefox", v.Browser, v.Class)
+			}
+
+			// an English Firefox ESR on Windows sends the headers of Tor
+			// Browser, so the policy applies to it as documented
+			headers = firefoxHeaders()
+			headers["User-Agent"] = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:140.0) Gecko/20100101 Firefox/140.0"
+			headers["Accept-Language"] = "en-US,en;q=0.5"
+			if v := h.Evaluate(newRequest("/", headers)); v.Class != tt.wantClass || v.Browser != useragent.BrowserTor {
+				t.Errorf("en-US Firefox ESR verdict = %q %q, want tor %q", v.Browser, v.Class, tt.wantClass)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+func TestCheckGreaseBrand(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"chrome 144", chromeHeaders(), ""},
+		{"opera on chromium 140", operaHeaders(), ""},
+		{"samsung internet on chromium 130", samsungHeaders(), ""},
+		{"edge webview2", webView2Headers(), ""},
+		{"grease brand of chrome 140", withHeaders(chromeHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Chromium";v="144", "Not=A?Brand";v="24", "Google Chrome";v="144"`,
+		}), ReasonGreaseBrandMismatch},
+		{"brands in alphabetical order", withHeaders(chromeHeaders(), map[string]string{
+			"Sec-Ch-Ua-Full-Version-List": `"Chromium";v="144.0.7559.60", "Google Chrome";v="144.0.7559.60", "Not(A:Brand";v="8.0.0.0"`,
+		}), ReasonGreaseBrandMismatch},
+		{"brave without grease brand", withHeaders(braveHeaders(), map[string]string{
+			"Sec-Ch-Ua": `"Chromium";v="144", "Brave";v="144"`,
+		}), ReasonGreaseBrandMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkGreaseBrand(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkGreaseBrand() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
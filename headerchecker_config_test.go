@@ -0,0 +1,238 @@
+package CaddyHeaderVerification
+
+import (
+	"strings"
+	"testing"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestUnmarshalCaddyfile(t *testing.T) {
+	input := `headerchecker {
+		profile_file /etc/caddy/profiles.json
+		disable accept_language devtools_path
+		check sec_fetch {
+			disabled
+		}
+		header_count chrome 20 40
+		version_floor firefox 128
+		release_file /etc/caddy/releases.json
+		release_floor releases 8
+		release_floor days 180
+		future_releases 2
+		accept firefox "text/html,*/*;q=0.8"
+		device_memory 4 8
+		response_header X-Bot-Check
+		score_thresholds 20 50
+		check old_browser {
+			weight 80
+			action reject 429 "slow down"
+		}
+		action bot error 403
+		check device_memory {
+			report_only
+		}
+		tor_policy block esr
+		in_app_policy challenge
+		in_app_packages com.facebook.katana com.instagram.android
+	}`
+
+	var h HeaderChecker
+	if err := h.UnmarshalCaddyfile(caddyfile.NewTestDispenser(input)); err != nil {
+		t.Fatalf("UnmarshalCaddyfile() error = %v", err)
+	}
+	if err := h.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if h.TorPolicy != TorPolicyBlock || !h.TorBlockESR {
+		t.Errorf("TorPolicy = %q, TorBlockESR = %v", h.TorPolicy, h.TorBlockESR)
+	}
+	if h.InAppPolicy != InAppPolicyChallenge {
+		t.Errorf("InAppPolicy = %q", h.InAppPolicy)
+	}
+	if len(h.InAppPackages) != 2 || h.InAppPackages[1] != "com.instagram.android" {
+		t.Errorf("InAppPackages = %v", h.InAppPackages)
+	}
+	if h.ReleaseFile != "/etc/caddy/releases.json" {
+		t.Errorf("ReleaseFile = %q", h.ReleaseFile)
+	}
+	if h.ReleaseFloor == nil || *h.ReleaseFloor != (ReleaseFloor{Releases: 8, Days: 180}) {
+		t.Errorf("ReleaseFloor = %+v", h.ReleaseFloor)
+	}
+	if got := h.futureReleases(); got != 2 {
+		t.Errorf("futureReleases() = %d, want 2", got)
+	}
+	if h.ProfileFile != "/etc/caddy/profiles.json" {
+		t.Errorf("ProfileFile = %q", h.ProfileFile)
+	}
+	for _, id := range []string{CheckAcceptLanguage, CheckDevtoolsPath, CheckSecFetch} {
+		if h.enabled(id) {
+			t.Errorf("check %q should be disabled", id)
+		}
+	}
+	if !h.enabled(CheckHeaderCount) {
+		t.Errorf("check %q should be enabled", CheckHeaderCount)
+	}
+	if got := h.HeaderCounts["chrome"]; got != (useragent.HeaderRange{Min: 20, Max: 40}) {
+		t.Errorf("HeaderCounts[chrome] = %+v", got)
+	}
+	if got := h.VersionFloors.Floor(useragent.FloorFirefox); got != 128 {
+		t.Errorf("firefox floor = %d, want 128", got)
+	}
+	if got := h.VersionFloors.Floor(useragent.FloorChrome); got != 140 {
+		t.Errorf("chrome floor = %d, want default 140", got)
+	}
+	firefox, _ := h.profile().Lookup(useragent.BrowserFirefox, 146)
+	if got := h.acceptFor(useragent.BrowserFirefox, firefox, useragent.DestinationDocument); got != "text/html,*/*;q=0.8" {
+		t.Errorf("acceptFor(firefox) = %q", got)
+	}
+	chrome, _ := h.profile().Lookup(useragent.BrowserChrome, 144)
+	if got := h.acceptFor(useragent.BrowserChrome, chrome, useragent.DestinationDocument); got != chrome.Accept[useragent.DestinationDocument] || got == "" {
+		t.Errorf("acceptFor(chrome) = %q, want profile value", got)
+	}
+	if got := h.deviceMemory(chrome); len(got) != 2 || got[0] != "4" || got[1] != "8" {
+		t.Errorf("deviceMemory() = %v", got)
+	}
+	if got := h.weight(CheckOldBrowser); got != 80 {
+		t.Errorf("weight(old_browser) = %d, want 80", got)
+	}
+	if got := h.weight(CheckHeaderCount); got != defaultWeights[CheckHeaderCount] {
+		t.Errorf("weight(header_count) = %d, want default", got)
+	}
+	if got := h.Checks[CheckOldBrowser].Action; got == nil || *got != (Action{Type: ActionReject, StatusCode: 429, Body: "slow down"}) {
+		t.Errorf("old_browser action = %+v", got)
+	}
+	if got := h.Actions["bot"]; got == nil || *got != (Action{Type: ActionError, StatusCode: 403}) {
+		t.Errorf("bot action = %+v", got)
+	}
+	if !h.reportOnly(CheckDeviceMemory) || h.reportOnly(CheckHeaderCount) {
+		t.Errorf("only device_memory should be report-only")
+	}
+	if h.suspiciousThreshold() != 20 || h.botThreshold() != 50 {
+		t.Errorf("thresholds = %d/%d, want 20/50", h.suspiciousThreshold(), h.botThreshold())
+	}
+	if got := h.responseHeader(); got != "X-Bot-Check" {
+		t.Errorf("responseHeader() = %q", got)
+	}
+}
+
+func TestUnmarshalCaddyfileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"argument on directive", `headerchecker foo`},
+		{"unknown subdirective", `headerchecker {
+			foo bar
+		}`},
+		{"header_count missing max", `headerchecker {
+			header_count chrome 20
+		}`},
+		{"header_count not a number", `headerchecker {
+			header_count chrome low 40
+		}`},
+		{"unknown release_floor unit", `headerchecker {
+			release_floor weeks 8
+		}`},
+		{"future_releases not a number", `headerchecker {
+			future_releases many
+		}`},
+		{"tor_policy with an unknown option", `headerchecker {
+			tor_policy block all
+		}`},
+		{"unknown check subdirective", `headerchecker {
+			check sec_fetch {
+				foo
+			}
+		}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h HeaderChecker
+			if err := h.UnmarshalCaddyfile(caddyfile.NewTestDispenser(tt.input)); err == nil {
+				t.Errorf("UnmarshalCaddyfile() expected an error")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		h       HeaderChecker
+		wantErr bool
+	}{
+		{"zero value", HeaderChecker{}, false},
+		{"unknown check", HeaderChecker{Checks: map[string]*CheckConfig{"nope": {}}}, true},
+		{"min greater than max", HeaderChecker{HeaderCounts: map[string]useragent.HeaderRange{"chrome": {Min: 30, Max: 20}}}, true},
+		{"unknown header_count browser", HeaderChecker{HeaderCounts: map[string]useragent.HeaderRange{"lynx": {Min: 1, Max: 2}}}, true},
+		{"header counts with disabled check", HeaderChecker{
+			Checks:       map[string]*CheckConfig{CheckHeaderCount: {Disabled: true}},
+			HeaderCounts: map[string]useragent.HeaderRange{"chrome": {Min: 20, Max: 30}},
+		}, true},
+		{"negative version floor", HeaderChecker{VersionFloors: useragent.VersionFloors{"chrome": -1}}, true},
+		{"unknown version floor", HeaderChecker{VersionFloors: useragent.VersionFloors{"lynx": 2}}, true},
+		{"negative release floor", HeaderChecker{ReleaseFloor: &ReleaseFloor{Days: -1}}, true},
+		{"release floor with disabled check", HeaderChecker{
+			Checks:       map[string]*CheckConfig{CheckOldBrowser: {Disabled: true}},
+			ReleaseFloor: &ReleaseFloor{Releases: 8},
+		}, true},
+		{"negative future releases", HeaderChecker{FutureReleases: intPtr(-1)}, true},
+		{"empty accept", HeaderChecker{AcceptHeaders: map[string]string{AcceptChrome: " "}}, true},
+		{"unknown accept key", HeaderChecker{AcceptHeaders: map[string]string{"lynx": "*/*"}}, true},
+		{"invalid device memory", HeaderChecker{DeviceMemory: []string{"3"}}, true},
+		{"device memory with disabled check", HeaderChecker{
+			Checks:       map[string]*CheckConfig{CheckDeviceMemory: {Disabled: true}},
+			DeviceMemory: []string{"8"},
+		}, true},
+		{"weight above max", HeaderChecker{Checks: map[string]*CheckConfig{CheckSecFetch: {Weight: intPtr(101)}}}, true},
+		{"weight on disabled check", HeaderChecker{Checks: map[string]*CheckConfig{CheckSecFetch: {Disabled: true, Weight: intPtr(10)}}}, true},
+		{"suspicious above bot", HeaderChecker{SuspiciousThreshold: intPtr(70), BotThreshold: intPtr(50)}, true},
+		{"bot threshold zero", HeaderChecker{BotThreshold: intPtr(0)}, true},
+		{"unknown action type", HeaderChecker{Actions: map[string]*Action{"bot": {Type: "drop"}}}, true},
+		{"unknown action class", HeaderChecker{Actions: map[string]*Action{"robot": {Type: ActionTag}}}, true},
+		{"blocking humans", HeaderChecker{Actions: map[string]*Action{"human": {Type: ActionReject}}}, true},
+		{"status on abort", HeaderChecker{Actions: map[string]*Action{"bot": {Type: ActionAbort, StatusCode: 403}}}, true},
+		{"action on disabled check", HeaderChecker{Checks: map[string]*CheckConfig{CheckSecFetch: {Disabled: true, Action: &Action{Type: ActionReject}}}}, true},
+		{"action on report-only check", HeaderChecker{Checks: map[string]*CheckConfig{CheckSecFetch: {ReportOnly: true, Action: &Action{Type: ActionReject}}}}, true},
+		{"report-only on disabled check", HeaderChecker{Checks: map[string]*CheckConfig{CheckSecFetch: {Disabled: true, ReportOnly: true}}}, true},
+		{"blocking bots while report-only", HeaderChecker{ReportOnly: true, Actions: map[string]*Action{"bot": {Type: ActionReject}}}, true},
+		{"response header name while disabled", HeaderChecker{ResponseHeader: "X-Bot", DisableResponseHeader: true}, true},
+		{"unknown tor policy", HeaderChecker{TorPolicy: "captcha"}, true},
+		{"tor block without esr", HeaderChecker{TorPolicy: TorPolicyBlock}, true},
+		{"tor block with esr", HeaderChecker{TorPolicy: TorPolicyBlock, TorBlockESR: true}, false},
+		{"esr without tor block", HeaderChecker{TorPolicy: TorPolicyChallenge, TorBlockESR: true}, true},
+		{"unknown in-app policy", HeaderChecker{InAppPolicy: "captcha"}, true},
+		{"in-app package that is no application id", HeaderChecker{InAppPackages: []string{"facebook"}}, true},
+		{"upstream header", HeaderChecker{Upstream: &UpstreamHeader{KeyID: "k1", Key: testUpstreamKey}}, false},
+		{"upstream header without key id", HeaderChecker{Upstream: &UpstreamHeader{Key: testUpstreamKey}}, true},
+		{"upstream header key id with separator", HeaderChecker{Upstream: &UpstreamHeader{KeyID: "k;1", Key: testUpstreamKey}}, true},
+		{"upstream header short key", HeaderChecker{Upstream: &UpstreamHeader{KeyID: "k1", Key: "secret"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.h.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsFirstKeyInOrder(t *testing.T) {
+	h := HeaderChecker{Checks: map[string]*CheckConfig{
+		CheckSecFetch:       {Weight: intPtr(101)},
+		CheckAcceptLanguage: {Weight: intPtr(101)},
+		CheckHeaderCount:    {Weight: intPtr(101)},
+	}}
+	for range 20 {
+		err := h.Validate()
+		if err == nil || !strings.HasPrefix(err.Error(), "check "+CheckAcceptLanguage+":") {
+			t.Fatalf("Validate() error = %v, want the error of %s", err, CheckAcceptLanguage)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package CaddyHeaderVerification
+
+import (
+	"testing"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+)
+
+// webViewHeaders are the headers Android WebView 144 sends for a top-level
+// navigation inside an app. WebView names the app in X-Requested-With.
+func webViewHeaders() map[string]string {
+	headers := androidChromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A.240105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/144.0.7559.59 Mobile Safari/537.36"
+	headers["Sec-Ch-Ua"] = `"Not(A:Brand";v="8", "Chromium";v="144", "Android WebView";v="144"`
+	headers["Sec-Ch-Ua-Full-Version-List"] = `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.59", "Android WebView";v="144.0.7559.59"`
+	headers["Sec-Ch-Ua-Full-Version"] = `"144.0.7559.59"`
+	headers["X-Requested-With"] = "com.example.news"
+	return headers
+}
+
+// facebookHeaders are the headers the Facebook in-app browser on Android
+// sends for a top-level navigation.
+func facebookHeaders() map[string]string {
+	headers := webViewHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A.240105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/144.0.7559.59 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/540.0.0.45.68;]"
+	headers["X-Requested-With"] = "com.facebook.katana"
+	return headers
+}
+
+func TestCheckClientHintVersionsWebView(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"webview brand", webViewHeaders(), ""},
+		{"webview without the webview brand", withHeaders(webViewHeaders(), map[string]string{
+			"Sec-Ch-Ua": androidChromeHeaders()["Sec-Ch-Ua"],
+		}), ReasonClientHintBrandMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkClientHintVersions(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkClientHintVersions() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckAppPackage(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowed    []string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"any app by default", nil, withHeaders(facebookHeaders(), map[string]string{"X-Requested-With": "com.example.scraper"}), ""},
+		{"app package from desktop chrome", nil, withHeaders(chromeHeaders(), map[string]string{"X-Requested-With": "com.example.news"}), ReasonAppPackageUnexpected},
+		{"xmlhttprequest is not an app package", nil, withHeaders(chromeHeaders(), map[string]string{"X-Requested-With": "XMLHttpRequest"}), ""},
+		{"allowed", []string{"com.instagram.android"}, withHeaders(facebookHeaders(), map[string]string{"X-Requested-With": "com.instagram.android"}), ""},
+		{"not allowed", []string{"com.instagram.android"}, facebookHeaders(), ReasonAppPackageNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HeaderChecker{InAppPackages: tt.allowed}
+			f := h.checkAppPackage(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkAppPackage() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestInAppPolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantClass VerdictClass
+	}{
+		{"", ClassHuman},
+		{InAppPolicyAllow, ClassHuman},
+		{InAppPolicyChallenge, ClassSuspicious},
+		{InAppPolicyBlock, ClassBot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			h := HeaderChecker{InAppPolicy: tt.policy}
+			apps := []struct {
+				headers func() map[string]string
+				browser useragent.BrowserKind
+			}{
+				{facebookHeaders, useragent.BrowserInApp},
+				{webViewHeaders, useragent.BrowserAndroidWebView},
+			}
+			for _, app := range apps {
+				v := h.Evaluate(newRequest("/", app.headers()))
+				if v.Browser != app.browser {
+					t.Errorf("Browser = %q, want %q", v.Browser, app.browser)
+				}
+				if v.Class != tt.wantClass {
+					t.Errorf("%s Class = %q, want %q (findings %+v)", app.browser, v.Class, tt.wantClass, v.Findings)
+				}
+			}
+
+			// the policy does not touch the browser itself
+			if v := h.Evaluate(newRequest("/", androidChromeHeaders())); v.Class != ClassHuman {
+				t.Errorf("Chrome for Android Class = %q, want human (findings %+v)", v.Class, v.Findings)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+// iosChromeHeaders are the headers Chrome 144 on an iPhone sends for a
+// top-level navigation. Like every iOS browser it is built on WebKit.
+func iosChromeHeaders() map[string]string {
+	headers := safariHeaders()
+	headers["Accept-Encoding"] = "gzip, deflate, br"
+	headers["User-Agent"] = "Mozilla/5.0 (iPhone; CPU iPhone OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/144.0.7559.85 Mobile/15E148 Safari/604.1"
+	return headers
+}
+
+func TestCheckSafariAcceptIOS(t *testing.T) {
+	var h HeaderChecker
+
+	if f := h.checkSafariAccept(newRequest("/", iosChromeHeaders())); f != nil {
+		t.Errorf("checkSafariAccept() of chrome on iphone = %+v, want nil", f)
+	}
+	headers := withHeaders(iosChromeHeaders(), map[string]string{"Accept": chromeHeaders()["Accept"]})
+	if f := h.checkSafariAccept(newRequest("/", headers)); f == nil || f.Reason != ReasonAcceptMismatch {
+		t.Errorf("checkSafariAccept() with the chrome accept = %+v, want reason %q", f, ReasonAcceptMismatch)
+	}
+}
+
+func TestIOSProfile(t *testing.T) {
+	var h HeaderChecker
+
+	headers := withHeaders(iosChromeHeaders(), map[string]string{"Sec-Ch-Ua": chromeHeaders()["Sec-Ch-Ua"]})
+	if f := h.checkRequiredHeaders(newRequest("/", headers)); f == nil || f.Reason != ReasonForbiddenHeader {
+		t.Errorf("checkRequiredHeaders() with client hints = %+v, want reason %q", f, ReasonForbiddenHeader)
+	}
+
+	headers = withHeaders(iosChromeHeaders(), map[string]string{
+		"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/144.0.7559.85 Mobile/15E148 Safari/604.1",
+	})
+	if f := h.checkUAGrammar(newRequest("/", headers)); f == nil || f.Reason != ReasonUAGrammarMismatch {
+		t.Errorf("checkUAGrammar() with a desktop platform = %+v, want reason %q", f, ReasonUAGrammarMismatch)
+	}
+}
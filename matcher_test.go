@@ -0,0 +1,135 @@
+package CaddyHeaderVerification
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	_ "github.com/caddyserver/caddy/v2/modules/standard"
+)
+
+func TestMatchHeaderVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher MatchHeaderVerdict
+		headers func() map[string]string
+		want    bool
+	}{
+		{"bot class matches curl", MatchHeaderVerdict{Classes: []string{"bot"}}, func() map[string]string {
+			return map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}
+		}, true},
+		{"bot class does not match chrome", MatchHeaderVerdict{Classes: []string{"bot"}}, chromeHeaders, false},
+		{"human class matches chrome", MatchHeaderVerdict{Classes: []string{"human"}}, chromeHeaders, true},
+		{"min score", MatchHeaderVerdict{MinScore: intPtr(50)}, func() map[string]string {
+			return map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}
+		}, true},
+		{"checker config is used", MatchHeaderVerdict{
+			Classes: []string{"bot"},
+			Checker: &HeaderChecker{ReportOnly: true},
+		}, func() map[string]string {
+			return map[string]string{"User-Agent": "curl/8.5.0", "Accept": "*/*"}
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := withCaddyContext(newRequest("/", tt.headers()))
+			got, err := tt.matcher.MatchWithError(req)
+			if err != nil {
+				t.Fatalf("MatchWithError() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchWithError() = %v, want %v", got, tt.want)
+			}
+			if _, ok := VerdictFromRequest(req); !ok {
+				t.Errorf("matcher did not store the verdict on the request")
+			}
+		})
+	}
+}
+
+func TestMatchHeaderVerdictUsesStoredVerdict(t *testing.T) {
+	req, _ := withCaddyContext(newRequest("/", chromeHeaders()))
+	publishVerdict(req, Verdict{Class: ClassBot, Score: 80})
+
+	got, err := MatchHeaderVerdict{Classes: []string{"bot"}}.MatchWithError(req)
+	if err != nil || !got {
+		t.Errorf("MatchWithError() = %v, %v, want the stored bot verdict to match", got, err)
+	}
+}
+
+func TestMatchHeaderVerdictUnmarshalCaddyfile(t *testing.T) {
+	input := `headerverdict bot {
+		class suspicious
+		min_score 40
+		checker {
+			disable accept_language
+		}
+	}`
+
+	var m MatchHeaderVerdict
+	if err := m.UnmarshalCaddyfile(caddyfile.NewTestDispenser(input)); err != nil {
+		t.Fatalf("UnmarshalCaddyfile() error = %v", err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(m.Classes) != 2 || m.Classes[0] != "bot" || m.Classes[1] != "suspicious" {
+		t.Errorf("Classes = %v", m.Classes)
+	}
+	if m.MinScore == nil || *m.MinScore != 40 {
+		t.Errorf("MinScore = %v", m.MinScore)
+	}
+	if m.Checker == nil || m.Checker.enabled(CheckAcceptLanguage) {
+		t.Errorf("Checker = %+v, want accept_language disabled", m.Checker)
+	}
+
+	if err := (&MatchHeaderVerdict{}).Validate(); err == nil {
+		t.Errorf("Validate() of an empty matcher should fail")
+	}
+	if err := (&MatchHeaderVerdict{Classes: []string{"robot"}}).Validate(); err == nil {
+		t.Errorf("Validate() with an unknown class should fail")
+	}
+}
+
+func TestCaddyfileAdapt(t *testing.T) {
+	input := "{\n" +
+		"\torder headerchecker before respond\n" +
+		"}\n" +
+		"\n" +
+		":8080 {\n" +
+		"\theaderchecker {\n" +
+		"\t\tdisable devtools_path\n" +
+		"\t\taction bot reject 403\n" +
+		"\t}\n" +
+		"\t@bots headerverdict bot\n" +
+		"\trespond @bots \"bot\"\n" +
+		"\trespond \"OK\"\n" +
+		"}\n"
+
+	adapter := caddyfile.Adapter{ServerType: httpcaddyfile.ServerType{}}
+	out, warnings, err := adapter.Adapt([]byte(input), nil)
+	if err != nil {
+		t.Fatalf("Adapt() error = %v", err)
+	}
+	if len(warnings) > 0 {
+		t.Errorf("Adapt() warnings = %v", warnings)
+	}
+
+	var cfg any
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		t.Fatalf("adapted config is not JSON: %v", err)
+	}
+	for _, want := range []string{
+		`"handler":"headerchecker"`,
+		`"checks":{"devtools_path":{"disabled":true}}`,
+		`"actions":{"bot":{"status_code":403,"type":"reject"}}`,
+		`"headerverdict":{"classes":["bot"]}`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("adapted config does not contain %s:\n%s", want, out)
+		}
+	}
+}
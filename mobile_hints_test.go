@@ -0,0 +1,44 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+func TestCheckMobileHints(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"chrome on android", androidChromeHeaders(), ""},
+		{"desktop chrome", chromeHeaders(), ""},
+		{"chrome on android claiming a desktop", withHeaders(androidChromeHeaders(), map[string]string{"Sec-Ch-Ua-Mobile": "?0"}), ReasonMobileHintMismatch},
+		{"chrome on android with an empty model", withHeaders(androidChromeHeaders(), map[string]string{"Sec-Ch-Ua-Model": `""`}), ReasonModelHintMismatch},
+		{"desktop chrome claiming a phone", withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Mobile": "?1"}), ReasonMobileHintMismatch},
+		{"desktop chrome with android platform", withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Platform": `"Android"`}), ReasonPlatformHintMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkMobileHints(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkMobileHints() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckDeviceMemoryAndroid(t *testing.T) {
+	var h HeaderChecker
+
+	if f := h.checkDeviceMemory(newRequest("/", androidChromeHeaders())); f != nil {
+		t.Errorf("checkDeviceMemory() of a phone = %+v, want nil", f)
+	}
+	headers := withHeaders(androidChromeHeaders(), map[string]string{"Sec-Ch-Device-Memory": "0.25"})
+	if f := h.checkDeviceMemory(newRequest("/", headers)); f == nil || f.Reason != ReasonDeviceMemoryUnexpected {
+		t.Errorf("checkDeviceMemory() of 0.25 GiB = %+v, want reason %q", f, ReasonDeviceMemoryUnexpected)
+	}
+}
@@ -0,0 +1,54 @@
+package CaddyHeaderVerification
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// withCaddyContext adds the replacer and vars table Caddy sets up for every request.
+func withCaddyContext(r *http.Request) (*http.Request, *caddy.Replacer) {
+	repl := caddy.NewReplacer()
+	ctx := context.WithValue(r.Context(), caddy.ReplacerCtxKey, repl)
+	ctx = context.WithValue(ctx, caddyhttp.VarsCtxKey, map[string]any{})
+	return r.WithContext(ctx), repl
+}
+
+func TestServeHTTPPublishesVerdict(t *testing.T) {
+	headers := firefoxHeaders()
+	headers["Accept-Charset"] = "utf-8"
+	req, repl := withCaddyContext(newRequest("/", headers))
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return nil })
+
+	if err := (HeaderChecker{}).ServeHTTP(httptest.NewRecorder(), req, next); err != nil {
+		t.Fatalf("ServeHTTP() error = %v", err)
+	}
+
+	placeholders := map[string]string{
+		"{http.headerchecker.class}":        string(ClassBot),
+		"{http.headerchecker.score}":        "60",
+		"{http.headerchecker.shadow_score}": "60",
+		"{http.headerchecker.browser}":      string(useragent.BrowserFirefox),
+		"{http.headerchecker.reasons}":      ReasonAcceptCharsetPresent,
+		"{http.headerchecker.checks}":       CheckAcceptCharset,
+		"{http.headerchecker.secure}":       "false",
+	}
+	for placeholder, want := range placeholders {
+		if got := repl.ReplaceAll(placeholder, ""); got != want {
+			t.Errorf("%s = %q, want %q", placeholder, got, want)
+		}
+	}
+
+	if got := caddyhttp.GetVar(req.Context(), VarScore); got != 60 {
+		t.Errorf("var %s = %v, want 60", VarScore, got)
+	}
+	v, ok := VerdictFromRequest(req)
+	if !ok || v.Class != ClassBot || v.Browser != useragent.BrowserFirefox {
+		t.Errorf("VerdictFromRequest() = %+v, %v", v, ok)
+	}
+}
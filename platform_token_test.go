@@ -0,0 +1,60 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+func TestCheckPlatformToken(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"chrome on windows", chromeHeaders(), true},
+		{"chrome on android", androidChromeHeaders(), true},
+		{"chrome on macos", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent":         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+			"Sec-Ch-Ua-Platform": `"macOS"`,
+		}), true},
+		{"macos platform hint with a windows user agent", withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Platform": `"macOS"`}), false},
+		{"unknown platform", withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Platform": `"Haiku"`}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkPlatformToken(newRequest("/", tt.headers))
+			if got := f == nil; got != tt.want {
+				t.Errorf("checkPlatformToken() = %+v, want valid %v", f, tt.want)
+			}
+			if f != nil && f.Reason != ReasonPlatformTokenMismatch {
+				t.Errorf("Reason = %q, want %q", f.Reason, ReasonPlatformTokenMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckLinuxPlatform(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name string
+		ua   string
+		want bool
+	}{
+		{"chrome on arm linux", "Mozilla/5.0 (X11; Linux aarch64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36", true},
+		{"linux platform hint with a chromeos user agent", "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := withHeaders(chromeHeaders(), map[string]string{
+				"User-Agent": tt.ua, "Sec-Ch-Ua-Platform": `"Linux"`, "Sec-Ch-Ua-Platform-Version": `""`,
+			})
+			f := h.checkLinuxPlatform(newRequest("/", headers))
+			if got := f == nil; got != tt.want {
+				t.Errorf("checkLinuxPlatform() = %+v, want valid %v", f, tt.want)
+			}
+			if f != nil && f.Reason != ReasonLinuxPlatformToken {
+				t.Errorf("Reason = %q, want %q", f.Reason, ReasonLinuxPlatformToken)
+			}
+		})
+	}
+}
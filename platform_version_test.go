@@ -0,0 +1,43 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+func TestCheckPlatformVersion(t *testing.T) {
+	var h HeaderChecker
+
+	const (
+		macUA   = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+		linuxUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36"
+	)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"chrome on macos", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent": macUA, "Sec-Ch-Ua-Platform": `"macOS"`, "Sec-Ch-Ua-Platform-Version": `"15.6.1"`,
+		}), true},
+		{"macos with the frozen user agent version", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent": macUA, "Sec-Ch-Ua-Platform": `"macOS"`, "Sec-Ch-Ua-Platform-Version": `"10.15.7"`,
+		}), false},
+		{"chrome on android", androidChromeHeaders(), true},
+		{"android with the frozen user agent model", withHeaders(androidChromeHeaders(), map[string]string{"Sec-Ch-Ua-Model": `"K"`}), false},
+		{"chrome on linux", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent": linuxUA, "Sec-Ch-Ua-Platform": `"Linux"`, "Sec-Ch-Ua-Platform-Version": `""`,
+		}), true},
+		{"linux with a platform version", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent": linuxUA, "Sec-Ch-Ua-Platform": `"Linux"`, "Sec-Ch-Ua-Platform-Version": `"6.8.0"`,
+		}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkPlatformVersion(newRequest("/", tt.headers))
+			if got := f == nil; got != tt.want {
+				t.Errorf("checkPlatformVersion() = %+v, want valid %v", f, tt.want)
+			}
+			if f != nil && f.Reason != ReasonPlatformVersion {
+				t.Errorf("Reason = %q, want %q", f.Reason, ReasonPlatformVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package CaddyHeaderVerification
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestProvisionProfileFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	data := `{
+		"version": 1,
+		"revision": "test",
+		"brands": ["Google Chrome"],
+		"platforms": ["Windows NT 10.0; Win64; x64"],
+		"browsers": {
+			"firefox": [{
+				"min_version": 140,
+				"accept": {"document": "text/html,*/*;q=0.8"},
+				"header_count": {"min": 5, "max": 20}
+			}]
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+	h := HeaderChecker{ProfileFile: path}
+	if err := h.Provision(ctx); err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+
+	v := h.Evaluate(newRequest("/", firefoxHeaders()))
+	got := v.Reasons()
+	if len(got) != 1 || got[0] != ReasonAcceptMismatch {
+		t.Errorf("Reasons() = %v, want [%s] from the loaded Accept value", got, ReasonAcceptMismatch)
+	}
+
+	h = HeaderChecker{ProfileFile: filepath.Join(t.TempDir(), "missing.json")}
+	if err := h.Provision(ctx); err == nil {
+		t.Errorf("Provision() with a missing profile_file expected an error")
+	}
+}
+
+func TestProvisionProfileFileWithoutPlatformTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	data := `{
+		"version": 1,
+		"brands": ["Google Chrome"],
+		"platforms": ["Windows NT 10.0; Win64; x64"]
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+	h := HeaderChecker{ProfileFile: path}
+	if err := h.Provision(ctx); err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+
+	headers := chromeHeaders()
+	headers["Sec-Ch-Ua-Platform"] = `"Linux"`
+	v := h.Evaluate(newRequest("/", headers))
+	if !slices.Contains(v.Reasons(), ReasonLinuxPlatformToken) {
+		t.Errorf("Reasons() = %v, want %s from the embedded platform tokens", v.Reasons(), ReasonLinuxPlatformToken)
+	}
+}
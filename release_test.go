@@ -0,0 +1,158 @@
+package CaddyHeaderVerification
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+	"github.com/caddyserver/caddy/v2"
+)
+
+// releaseDay is a fixed day in the embedded release data: Chrome 153 and
+// Firefox 156 are the latest releases.
+var releaseDay = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+
+// withChromeVersion returns chromeHeaders with the major version in the UA
+// and client hints set to major, and the brand lists Chrome major sends.
+func withChromeVersion(major int) map[string]string {
+	headers := chromeHeaders()
+	version := strconv.Itoa(major)
+	for _, name := range []string{"User-Agent", "Sec-Ch-Ua-Full-Version"} {
+		headers[name] = strings.ReplaceAll(headers[name], "144", version)
+	}
+	var brands, fullBrands []string
+	for _, brand := range useragent.GreasedBrandOrder(major, useragent.BrandChrome) {
+		v, full := version, version+".0.7559.60"
+		if useragent.IsGreaseBrand(brand) {
+			v, full = useragent.GreaseBrand(major, false).Version, useragent.GreaseBrand(major, true).Version
+		}
+		brands = append(brands, fmt.Sprintf("%q;v=%q", brand, v))
+		fullBrands = append(fullBrands, fmt.Sprintf("%q;v=%q", brand, full))
+	}
+	headers["Sec-Ch-Ua"] = strings.Join(brands, ", ")
+	headers["Sec-Ch-Ua-Full-Version-List"] = strings.Join(fullBrands, ", ")
+	return headers
+}
+
+func TestReleaseFloor(t *testing.T) {
+	tests := []struct {
+		name        string
+		floor       *ReleaseFloor
+		major       int
+		wantReasons []string
+	}{
+		{"no floor", nil, 144, nil},
+		{"within releases", &ReleaseFloor{Releases: 10}, 144, nil},
+		{"behind releases", &ReleaseFloor{Releases: 8}, 144, []string{ReasonBrowserTooOld}},
+		{"within days", &ReleaseFloor{Days: 300}, 144, nil},
+		{"superseded too long ago", &ReleaseFloor{Days: 200}, 144, []string{ReasonBrowserTooOld}},
+		{"latest release", &ReleaseFloor{Releases: 1, Days: 1}, 153, nil},
+		{"static floor still applies", &ReleaseFloor{Releases: 20}, 139, []string{ReasonBrowserTooOld}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HeaderChecker{ReleaseFloor: tt.floor, now: releaseDay}
+			v := h.Evaluate(newRequest("/", withChromeVersion(tt.major)))
+			if !slices.Equal(v.Reasons(), tt.wantReasons) {
+				t.Errorf("Reasons() = %v, want %v (findings %+v)", v.Reasons(), tt.wantReasons, v.Findings)
+			}
+		})
+	}
+}
+
+func TestUnreleasedVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		future      *int
+		major       int
+		wantReasons []string
+	}{
+		{"canary", nil, 156, nil},
+		{"ahead of canary", nil, 157, []string{ReasonBrowserUnreleased}},
+		{"far future", nil, 200, []string{ReasonBrowserUnreleased}},
+		{"stable only", intPtr(0), 154, []string{ReasonBrowserUnreleased}},
+		{"stable only, latest", intPtr(0), 153, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HeaderChecker{FutureReleases: tt.future, now: releaseDay}
+			v := h.Evaluate(newRequest("/", withChromeVersion(tt.major)))
+			if !slices.Equal(v.Reasons(), tt.wantReasons) {
+				t.Errorf("Reasons() = %v, want %v (findings %+v)", v.Reasons(), tt.wantReasons, v.Findings)
+			}
+		})
+	}
+
+	// Firefox is checked against its own releases
+	h := HeaderChecker{now: releaseDay}
+	headers := firefoxHeaders()
+	headers["User-Agent"] = strings.ReplaceAll(headers["User-Agent"], "146.0", "160.0")
+	v := h.Evaluate(newRequest("/", headers))
+	if !slices.Equal(v.Reasons(), []string{ReasonBrowserUnreleased}) {
+		t.Errorf("Firefox 160 Reasons() = %v, want [%s]", v.Reasons(), ReasonBrowserUnreleased)
+	}
+	// the release data ages, so the finding alone does not make a request suspicious
+	if v.Class != ClassHuman {
+		t.Errorf("Firefox 160 Class = %q, want %q", v.Class, ClassHuman)
+	}
+}
+
+func TestUnreleasedVersionAfterReleaseData(t *testing.T) {
+	// The embedded data ends with Chrome 155 in November 2026. A year later
+	// current Chrome releases are well past it.
+	later := func() time.Time { return time.Date(2028, 1, 1, 12, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name        string
+		major       int
+		wantReasons []string
+	}{
+		{"last release in the data", 155, nil},
+		{"current release", 167, nil},
+		{"far future", 200, []string{ReasonBrowserUnreleased}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HeaderChecker{now: later}
+			v := h.Evaluate(newRequest("/", withChromeVersion(tt.major)))
+			if !slices.Equal(v.Reasons(), tt.wantReasons) {
+				t.Errorf("Reasons() = %v, want %v (findings %+v)", v.Reasons(), tt.wantReasons, v.Findings)
+			}
+		})
+	}
+}
+
+func TestProvisionReleaseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "releases.json")
+	data := `{
+		"version": 1,
+		"revision": "test",
+		"browsers": {"chrome": {"139": "2026-09-15", "140": "2026-10-13"}}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	defer cancel()
+	h := HeaderChecker{ReleaseFile: path, now: releaseDay}
+	if err := h.Provision(ctx); err != nil {
+		t.Fatalf("Provision() error = %v", err)
+	}
+
+	v := h.Evaluate(newRequest("/", chromeHeaders()))
+	if !slices.Equal(v.Reasons(), []string{ReasonBrowserUnreleased}) {
+		t.Errorf("Reasons() = %v, want [%s] from the loaded releases", v.Reasons(), ReasonBrowserUnreleased)
+	}
+
+	h = HeaderChecker{ReleaseFile: filepath.Join(t.TempDir(), "missing.json")}
+	if err := h.Provision(ctx); err == nil {
+		t.Errorf("Provision() with a missing release_file expected an error")
+	}
+}
@@ -0,0 +1,62 @@
+package CaddyHeaderVerification
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReportOnlyCheck(t *testing.T) {
+	h := HeaderChecker{Checks: map[string]*CheckConfig{
+		CheckAcceptCharset: {ReportOnly: true},
+	}}
+	headers := firefoxHeaders()
+	headers["Accept-Charset"] = "utf-8"
+
+	v := h.Evaluate(newRequest("/", headers))
+	if v.Class != ClassHuman || v.Score != 0 {
+		t.Errorf("verdict = %s/%d, want human/0", v.Class, v.Score)
+	}
+	if len(v.Findings) != 0 {
+		t.Errorf("Findings = %+v, want none", v.Findings)
+	}
+	if len(v.Reported) != 1 || v.Reported[0].Reason != ReasonAcceptCharsetPresent {
+		t.Errorf("Reported = %+v", v.Reported)
+	}
+	if v.ShadowScore != defaultWeights[CheckAcceptCharset] {
+		t.Errorf("ShadowScore = %d, want %d", v.ShadowScore, defaultWeights[CheckAcceptCharset])
+	}
+}
+
+func TestReportOnlyHandler(t *testing.T) {
+	h := HeaderChecker{ReportOnly: true}
+	req := botRequest()
+
+	v := h.Evaluate(req)
+	if v.Class != ClassHuman || v.Score != 0 || len(v.Findings) != 0 {
+		t.Errorf("verdict = %+v, want human with no findings", v)
+	}
+	if len(v.Reported) == 0 || v.ShadowScore != MaxScore {
+		t.Errorf("Reported = %d findings, ShadowScore = %d", len(v.Reported), v.ShadowScore)
+	}
+}
+
+func TestFindingsMetric(t *testing.T) {
+	if err := initMetrics(prometheus.NewRegistry()); err != nil {
+		t.Fatalf("initMetrics() error = %v", err)
+	}
+	counter := headerCheckerMetrics.findings.WithLabelValues(CheckAcceptCharset, ReasonAcceptCharsetPresent, modeReportOnly)
+	before := testutil.ToFloat64(counter)
+
+	h := HeaderChecker{Checks: map[string]*CheckConfig{
+		CheckAcceptCharset: {ReportOnly: true},
+	}}
+	headers := firefoxHeaders()
+	headers["Accept-Charset"] = "utf-8"
+	h.Evaluate(newRequest("/", headers))
+
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("report-only findings counted = %v, want 1", got)
+	}
+}
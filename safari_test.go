@@ -0,0 +1,59 @@
+package CaddyHeaderVerification
+
+import "testing"
+
+// safariHeaders are the headers Safari 18 on macOS sends for a top-level
+// navigation over HTTP/2.
+func safariHeaders() map[string]string {
+	return map[string]string{
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Encoding": "gzip, deflate, br",
+		"Accept-Language": "en-GB,en;q=0.9",
+		"Priority":        "u=0, i",
+		"Sec-Fetch-Dest":  "document",
+		"Sec-Fetch-Mode":  "navigate",
+		"Sec-Fetch-Site":  "none",
+		"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.5 Safari/605.1.15",
+	}
+}
+
+func TestCheckSafariAccept(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"safari", safariHeaders(), ""},
+		{"chrome accept", withHeaders(safariHeaders(), map[string]string{"Accept": chromeHeaders()["Accept"]}), ReasonAcceptMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkSafariAccept(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkSafariAccept() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSafariProfile(t *testing.T) {
+	var h HeaderChecker
+
+	headers := withHeaders(safariHeaders(), map[string]string{"Sec-Ch-Ua-Mobile": "?0"})
+	if f := h.checkRequiredHeaders(newRequest("/", headers)); f == nil || f.Reason != ReasonForbiddenHeader {
+		t.Errorf("checkRequiredHeaders() with client hints = %+v, want reason %q", f, ReasonForbiddenHeader)
+	}
+
+	headers = withHeaders(safariHeaders(), map[string]string{
+		"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) Version/18.5 Safari/605.1.15",
+	})
+	if f := h.checkUAGrammar(newRequest("/", headers)); f == nil || f.Reason != ReasonUAGrammarMismatch {
+		t.Errorf("checkUAGrammar() of a malformed User-Agent = %+v, want reason %q", f, ReasonUAGrammarMismatch)
+	}
+}
@@ -0,0 +1,120 @@
+package CaddyHeaderVerification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		h     HeaderChecker
+		score int
+		want  VerdictClass
+	}{
+		{"zero is human", HeaderChecker{}, 0, ClassHuman},
+		{"below suspicious", HeaderChecker{}, DefaultSuspiciousThreshold - 1, ClassHuman},
+		{"at suspicious", HeaderChecker{}, DefaultSuspiciousThreshold, ClassSuspicious},
+		{"at bot", HeaderChecker{}, DefaultBotThreshold, ClassBot},
+		{"custom thresholds", HeaderChecker{SuspiciousThreshold: intPtr(10), BotThreshold: intPtr(20)}, 15, ClassSuspicious},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.classify(tt.score); got != tt.want {
+				t.Errorf("classify(%d) = %q, want %q", tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerdictAdd(t *testing.T) {
+	h := HeaderChecker{Checks: map[string]*CheckConfig{
+		CheckAcceptLanguage: {Weight: intPtr(70)},
+	}}
+
+	var v Verdict
+	v.add(h, Finding{Check: CheckAcceptLanguage, Reason: ReasonAcceptLanguageMissing})
+	if v.Score != 70 {
+		t.Fatalf("Score = %d, want configured weight 70", v.Score)
+	}
+	v.add(h, Finding{Check: CheckSecChUaBrand, Reason: ReasonSecChUaUnknownBrand})
+	if v.Score != MaxScore {
+		t.Fatalf("Score = %d, want it capped at %d", v.Score, MaxScore)
+	}
+	if got := v.FailedChecks(); len(got) != 2 || got[0] != CheckAcceptLanguage || got[1] != CheckSecChUaBrand {
+		t.Errorf("FailedChecks() = %v", got)
+	}
+	if got := v.Reasons(); len(got) != 2 || got[0] != ReasonAcceptLanguageMissing || got[1] != ReasonSecChUaUnknownBrand {
+		t.Errorf("Reasons() = %v", got)
+	}
+}
+
+func TestServeHTTPScoreHeaders(t *testing.T) {
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return nil })
+
+	t.Run("curl is a bot", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		req.Header.Set("User-Agent", "curl/8.5.0")
+		req.Header.Set("Accept", "*/*")
+		rec := httptest.NewRecorder()
+
+		if err := (HeaderChecker{}).ServeHTTP(rec, req, next); err != nil {
+			t.Fatalf("ServeHTTP() error = %v", err)
+		}
+		if got := rec.Header().Get("SecureHeader"); got != "false" {
+			t.Errorf("SecureHeader = %q, want false", got)
+		}
+		if got := rec.Header().Get("SecureHeader-Class"); got != string(ClassBot) {
+			t.Errorf("SecureHeader-Class = %q, want bot", got)
+		}
+		if got := rec.Header().Get("SecureHeader-Score"); got != "100" {
+			t.Errorf("SecureHeader-Score = %q, want 100", got)
+		}
+	})
+
+	t.Run("low weights stay human", func(t *testing.T) {
+		h := HeaderChecker{Checks: map[string]*CheckConfig{
+			CheckUAReduction:    {Weight: intPtr(0)},
+			CheckAcceptWildcard: {Weight: intPtr(0)},
+			CheckSecFetch:       {Weight: intPtr(0)},
+			CheckAcceptLanguage: {Weight: intPtr(5)},
+		}}
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		req.Header.Set("User-Agent", "curl/8.5.0")
+		req.Header.Set("Accept", "*/*")
+		rec := httptest.NewRecorder()
+
+		if err := h.ServeHTTP(rec, req, next); err != nil {
+			t.Fatalf("ServeHTTP() error = %v", err)
+		}
+		if got := rec.Header().Get("SecureHeader"); got != "true" {
+			t.Errorf("SecureHeader = %q, want true", got)
+		}
+		if got := rec.Header().Get("SecureHeader-Score"); got != "5" {
+			t.Errorf("SecureHeader-Score = %q, want 5", got)
+		}
+	})
+}
+
+func TestLogOnlyChecksStayHuman(t *testing.T) {
+	if sum := defaultWeights[CheckSecFetch] + defaultWeights[CheckAcceptLanguage] + defaultWeights[CheckDevtoolsPath]; sum >= DefaultSuspiciousThreshold {
+		t.Fatalf("default weights of the log-only checks add up to %d, want less than %d", sum, DefaultSuspiciousThreshold)
+	}
+
+	// Required headers and the header count are checks of their own.
+	h := HeaderChecker{Checks: map[string]*CheckConfig{CheckRequiredHeaders: {Disabled: true}, CheckHeaderCount: {Disabled: true}}}
+	headers := chromeHeaders()
+	for _, name := range []string{"Accept-Language", "Sec-Fetch-Site", "Sec-Fetch-Mode", "Sec-Fetch-Dest", "Sec-Fetch-User"} {
+		delete(headers, name)
+	}
+	v := h.Evaluate(newRequest(DevtoolsPath, headers))
+	if v.Class != ClassHuman || len(v.Findings) != 3 {
+		t.Errorf("verdict = %s/%d with findings %v, want human with the three log-only findings", v.Class, v.Score, v.Reasons())
+	}
+}
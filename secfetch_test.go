@@ -5,7 +5,7 @@ import (
 	"testing"
 )
 
-func TestValidateSecFetchRequests(t *testing.T) {
+func TestCheckSecFetch(t *testing.T) {
 	h := HeaderChecker{}
 
 	tests := []struct {
@@ -83,9 +83,9 @@ func TestValidateSecFetchRequests(t *testing.T) {
 				req.Header.Set(k, v)
 			}
 
-			got := h.validateSecFetchRequests(req)
-			if got != tt.wantValid {
-				t.Errorf("validateSecFetchRequests() = %v, want %v", got, tt.wantValid)
+			got := h.checkSecFetch(req)
+			if (got == nil) != tt.wantValid {
+				t.Errorf("checkSecFetch() = %+v, want valid %v", got, tt.wantValid)
 			}
 		})
 	}
@@ -0,0 +1,134 @@
+package CaddyHeaderVerification
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+
+	verdictheader "github.com/IgnifexLabs/CaddyHeaderVerification/VerdictHeader"
+)
+
+const testUpstreamKey = "0123456789abcdef0123456789abcdef"
+
+func TestUnmarshalCaddyfileUpstreamHeader(t *testing.T) {
+	input := `headerchecker {
+		upstream_header {
+			header X-Verdict
+			key_id k1
+			key {env.HEADERCHECKER_TEST_KEY}
+			strip X-Bot-Score X-Bot-Class
+		}
+	}`
+
+	var h HeaderChecker
+	if err := h.UnmarshalCaddyfile(caddyfile.NewTestDispenser(input)); err != nil {
+		t.Fatalf("UnmarshalCaddyfile() error = %v", err)
+	}
+	u := h.Upstream
+	if u == nil || u.Header != "X-Verdict" || u.KeyID != "k1" || u.Key != "{env.HEADERCHECKER_TEST_KEY}" || len(u.Strip) != 2 {
+		t.Fatalf("Upstream = %+v", u)
+	}
+
+	t.Setenv("HEADERCHECKER_TEST_KEY", testUpstreamKey)
+	u.provision()
+	if got := string(u.secret()); got != testUpstreamKey {
+		t.Errorf("secret() = %q, want the environment value", got)
+	}
+}
+
+func TestServeHTTPUpstreamHeader(t *testing.T) {
+	h := HeaderChecker{
+		// the forged headers would otherwise exceed the Firefox header count
+		Checks:   map[string]*CheckConfig{CheckHeaderCount: {Disabled: true}},
+		Upstream: &UpstreamHeader{KeyID: "k1", Key: testUpstreamKey, Strip: []string{"X-Bot"}},
+	}
+
+	headers := firefoxHeaders()
+	headers["Accept-Charset"] = "utf-8"
+	headers["SecureHeader"] = "true"
+	headers["X-Bot"] = "no"
+	headers[verdictheader.DefaultHeader] = "v=1;kid=k1;ts=0;score=0;class=human;reasons=;sig=forged"
+	req := newRequest("/", headers)
+
+	var upstream *http.Request
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		upstream = r
+		return nil
+	})
+	if err := h.ServeHTTP(httptest.NewRecorder(), req, next); err != nil {
+		t.Fatalf("ServeHTTP() error = %v", err)
+	}
+
+	for _, name := range []string{"SecureHeader", "X-Bot"} {
+		if got := upstream.Header.Get(name); got != "" {
+			t.Errorf("client header %s = %q was passed on", name, got)
+		}
+	}
+
+	verifier := verdictheader.Verifier{
+		Keys:   map[string][]byte{"k1": []byte(testUpstreamKey)},
+		MaxAge: time.Minute,
+	}
+	v, err := verifier.VerifyRequest(upstream)
+	if err != nil {
+		t.Fatalf("VerifyRequest() error = %v", err)
+	}
+	if v.Score != 60 || v.Class != string(ClassBot) || len(v.Reasons) != 1 || v.Reasons[0] != ReasonAcceptCharsetPresent {
+		t.Errorf("verdict = %+v", v)
+	}
+}
+
+func TestServeHTTPStripsVerdictHeaderWithoutSigning(t *testing.T) {
+	headers := firefoxHeaders()
+	headers[verdictheader.DefaultHeader] = "forged"
+	req := newRequest("/", headers)
+
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if got := r.Header.Get(verdictheader.DefaultHeader); got != "" {
+			t.Errorf("client verdict header %q was passed on", got)
+		}
+		return nil
+	})
+	if err := (HeaderChecker{}).ServeHTTP(httptest.NewRecorder(), req, next); err != nil {
+		t.Fatalf("ServeHTTP() error = %v", err)
+	}
+}
+
+func TestSignVerdictUsesClock(t *testing.T) {
+	h := HeaderChecker{
+		Upstream: &UpstreamHeader{KeyID: "k1", Key: testUpstreamKey},
+		now:      releaseDay,
+	}
+	req := newRequest("/", firefoxHeaders())
+	h.signVerdict(req, Verdict{Score: 30, Class: ClassSuspicious})
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		wantErr error
+	}{
+		{"fresh", 30 * time.Second, nil},
+		{"expired", 2 * time.Minute, verdictheader.ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := verdictheader.Verifier{
+				Keys:   map[string][]byte{"k1": []byte(testUpstreamKey)},
+				MaxAge: time.Minute,
+				Now:    func() time.Time { return releaseDay().Add(tt.age) },
+			}
+			v, err := verifier.VerifyRequest(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyRequest() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !v.Timestamp.Equal(releaseDay()) {
+				t.Errorf("Timestamp = %v, want the handler clock %v", v.Timestamp, releaseDay())
+			}
+		})
+	}
+}
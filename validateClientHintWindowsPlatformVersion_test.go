@@ -12,27 +12,75 @@ func TestValidateClientHintWindowsPlatformVersion(t *testing.T) {
 		want            bool
 	}{
 		{
-			name:            "Windows with correct version 19.0.0",
-			platform:        `"\"Windows\""`,
+			name:            "Windows 11 24H2",
+			platform:        `"Windows"`,
 			platformVersion: `"19.0.0"`,
 			want:            true,
 		},
 		{
-			name:            "Windows with incorrect version 18.0.0",
-			platform:        `"\"Windows\""`,
+			name:            "Windows 11 22H2",
+			platform:        `"Windows"`,
+			platformVersion: `"15.0.0"`,
+			want:            true,
+		},
+		{
+			name:            "Windows 10 22H2",
+			platform:        `"Windows"`,
+			platformVersion: `"10.0.0"`,
+			want:            true,
+		},
+		{
+			name:            "Windows 10 1809",
+			platform:        `"Windows"`,
+			platformVersion: `"7.0.0"`,
+			want:            true,
+		},
+		{
+			name:            "Windows 11 release after the build table",
+			platform:        `"Windows"`,
+			platformVersion: `"21.0.0"`,
+			want:            true,
+		},
+		{
+			name:            "Windows with unknown version 18.0.0",
+			platform:        `"Windows"`,
 			platformVersion: `"18.0.0"`,
 			want:            false,
 		},
+		{
+			name:            "Windows with version between 10 and 11",
+			platform:        `"Windows"`,
+			platformVersion: `"11.0.0"`,
+			want:            false,
+		},
+		{
+			name:            "Windows 8.1",
+			platform:        `"Windows"`,
+			platformVersion: `"0.0.0"`,
+			want:            false,
+		},
+		{
+			name:            "Windows NT version",
+			platform:        `"Windows"`,
+			platformVersion: `"10.0"`,
+			want:            false,
+		},
 		{
 			name:            "Windows with missing version header",
-			platform:        `"\"Windows\""`,
+			platform:        `"Windows"`,
 			platformVersion: "",
 			want:            false,
 		},
 		{
 			name:            "Windows with empty version value",
-			platform:        `"\"Windows\""`,
-			platformVersion: "",
+			platform:        `"Windows"`,
+			platformVersion: `""`,
+			want:            false,
+		},
+		{
+			name:            "Windows with unquoted version",
+			platform:        `"Windows"`,
+			platformVersion: "19.0.0",
 			want:            false,
 		},
 		{
@@ -41,6 +89,12 @@ func TestValidateClientHintWindowsPlatformVersion(t *testing.T) {
 			platformVersion: "19.0.0",
 			want:            false,
 		},
+		{
+			name:            "Windows quoted twice",
+			platform:        `"\"Windows\""`,
+			platformVersion: `"19.0.0"`,
+			want:            false,
+		},
 		{
 			name:            "Non-Windows platform (macOS) with any version → allowed",
 			platform:        `"macOS"`,
@@ -92,3 +146,32 @@ func TestValidateClientHintWindowsPlatformVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckWindowsPlatformVersion(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantReason string
+	}{
+		{"chrome on windows 11", chromeHeaders(), ""},
+		{"chrome on windows 10", withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Platform-Version": `"10.0.0"`}), ""},
+		{"unknown windows build", withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Platform-Version": `"17.0.0"`}), ReasonWindowsPlatformVersion},
+		{"windows platform hint with a mac user agent", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+		}), ReasonWindowsPlatformVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := h.checkWindowsPlatformVersion(newRequest("/", tt.headers))
+			var got string
+			if f != nil {
+				got = f.Reason
+			}
+			if got != tt.wantReason {
+				t.Errorf("checkWindowsPlatformVersion() = %+v, want reason %q", f, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package CaddyHeaderVerification
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	useragent "github.com/IgnifexLabs/CaddyHeaderVerification/UserAgent"
+)
+
+// chromeHeaders are the headers Chrome 144 on Windows sends for a top-level
+// navigation when all client hints are requested.
+func chromeHeaders() map[string]string {
+	return map[string]string{
+		"Accept":                              "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+		"Accept-Encoding":                     "gzip, deflate, br, zstd",
+		"Accept-Language":                     "nl,nl-NL;q=0.9,en-US;q=0.8,en;q=0.7",
+		"Cache-Control":                       "max-age=0",
+		"Priority":                            "u=0, i",
+		"Sec-Ch-Device-Memory":                "8",
+		"Sec-Ch-Dpr":                          "1",
+		"Sec-Ch-Prefers-Color-Scheme":         "light",
+		"Sec-Ch-Prefers-Reduced-Motion":       "no-preference",
+		"Sec-Ch-Prefers-Reduced-Transparency": "no-preference",
+		"Sec-Ch-Ua":                           `"Not(A:Brand";v="8", "Chromium";v="144", "Google Chrome";v="144"`,
+		"Sec-Ch-Ua-Arch":                      `"x86"`,
+		"Sec-Ch-Ua-Bitness":                   `"64"`,
+		"Sec-Ch-Ua-Form-Factors":              `"Desktop"`,
+		"Sec-Ch-Ua-Full-Version":              `"144.0.7559.60"`,
+		"Sec-Ch-Ua-Full-Version-List":         `"Not(A:Brand";v="8.0.0.0", "Chromium";v="144.0.7559.60", "Google Chrome";v="144.0.7559.60"`,
+		"Sec-Ch-Ua-Mobile":                    "?0",
+		"Sec-Ch-Ua-Model":                     `""`,
+		"Sec-Ch-Ua-Platform":                  `"Windows"`,
+		"Sec-Ch-Ua-Platform-Version":          `"19.0.0"`,
+		"Sec-Ch-Ua-Wow64":                     "?0",
+		"Sec-Ch-Viewport-Height":              "945",
+		"Sec-Ch-Viewport-Width":               "1920",
+		"Sec-Fetch-Dest":                      "document",
+		"Sec-Fetch-Mode":                      "navigate",
+		"Sec-Fetch-Site":                      "none",
+		"Sec-Fetch-User":                      "?1",
+		"Upgrade-Insecure-Requests":           "1",
+		"User-Agent":                          "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36",
+	}
+}
+
+// androidChromeHeaders are the headers Chrome 144 on an Android phone sends
+// for a top-level navigation when all client hints are requested.
+func androidChromeHeaders() map[string]string {
+	headers := chromeHeaders()
+	headers["User-Agent"] = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Mobile Safari/537.36"
+	headers["Sec-Ch-Device-Memory"] = "4"
+	headers["Sec-Ch-Dpr"] = "2.625"
+	headers["Sec-Ch-Ua-Arch"] = `""`
+	headers["Sec-Ch-Ua-Bitness"] = `""`
+	headers["Sec-Ch-Ua-Form-Factors"] = `"Mobile"`
+	headers["Sec-Ch-Ua-Mobile"] = "?1"
+	headers["Sec-Ch-Ua-Model"] = `"Pixel 7"`
+	headers["Sec-Ch-Ua-Platform"] = `"Android"`
+	headers["Sec-Ch-Ua-Platform-Version"] = `"14.0.0"`
+	headers["Sec-Ch-Viewport-Height"] = "732"
+	headers["Sec-Ch-Viewport-Width"] = "412"
+	return headers
+}
+
+// firefoxHeaders are the headers Firefox 146 on Windows sends for a top-level
+// navigation over HTTP/2.
+func firefoxHeaders() map[string]string {
+	return map[string]string{
+		"Accept":                    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Encoding":           "gzip, deflate, br, zstd",
+		"Accept-Language":           "en-US,en;q=0.5",
+		"Priority":                  "u=0, i",
+		"Sec-Fetch-Dest":            "document",
+		"Sec-Fetch-Mode":            "navigate",
+		"Sec-Fetch-Site":            "none",
+		"Sec-Fetch-User":            "?1",
+		"Te":                        "trailers",
+		"Upgrade-Insecure-Requests": "1",
+		"User-Agent":                "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0",
+	}
+}
+
+func newRequest(path string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest("GET", "http://example.com"+path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+// withHeaders returns a copy of headers with the values in set. An empty
+// value removes the header.
+func withHeaders(headers map[string]string, set map[string]string) map[string]string {
+	out := maps.Clone(headers)
+	for k, v := range set {
+		if v == "" {
+			delete(out, k)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func TestEvaluate(t *testing.T) {
+	h := HeaderChecker{}
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantClass   VerdictClass
+		wantReasons []string
+	}{
+		{
+			name:      "chrome navigation",
+			headers:   chromeHeaders(),
+			wantClass: ClassHuman,
+		},
+		{
+			name:      "firefox navigation",
+			headers:   firefoxHeaders(),
+			wantClass: ClassHuman,
+		},
+		{
+			name:        "desktop chrome with android platform",
+			headers:     withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Platform": `"Android"`}),
+			wantClass:   ClassBot,
+			wantReasons: []string{ReasonPlatformTokenMismatch, ReasonPlatformHintMismatch},
+		},
+		{
+			name:        "chrome without accept-language",
+			headers:     withHeaders(chromeHeaders(), map[string]string{"Accept-Language": ""}),
+			wantClass:   ClassHuman,
+			wantReasons: []string{ReasonAcceptLanguageMissing},
+		},
+		{
+			name:        "headless chrome brand",
+			headers:     withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua": `"Not(A:Brand";v="8", "Chromium";v="144", "HeadlessChrome";v="144"`}),
+			wantClass:   ClassBot,
+			wantReasons: []string{ReasonClientHintVersionMismatch, ReasonSecChUaUnknownBrand},
+		},
+		{
+			name:        "chrome with unusual accept-encoding",
+			headers:     withHeaders(chromeHeaders(), map[string]string{"Accept-Encoding": "gzip"}),
+			wantClass:   ClassHuman,
+			wantReasons: []string{ReasonAcceptEncodingMismatch},
+		},
+		{
+			name:        "chrome without sec-ch-ua-mobile",
+			headers:     withHeaders(chromeHeaders(), map[string]string{"Sec-Ch-Ua-Mobile": ""}),
+			wantClass:   ClassHuman,
+			wantReasons: []string{ReasonRequiredHeaderMissing},
+		},
+		{
+			name:        "firefox with accept-charset",
+			headers:     withHeaders(firefoxHeaders(), map[string]string{"Accept-Charset": "utf-8"}),
+			wantClass:   ClassBot,
+			wantReasons: []string{ReasonAcceptCharsetPresent},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := h.Evaluate(newRequest("/", tt.headers))
+			if v.Class != tt.wantClass {
+				t.Errorf("Class = %q, want %q (findings %+v)", v.Class, tt.wantClass, v.Findings)
+			}
+			got := v.Reasons()
+			if len(got) != len(tt.wantReasons) {
+				t.Fatalf("Reasons() = %v, want %v", got, tt.wantReasons)
+			}
+			for i := range got {
+				if got[i] != tt.wantReasons[i] {
+					t.Errorf("Reasons() = %v, want %v", got, tt.wantReasons)
+				}
+			}
+		})
+	}
+}
+
+// TestEvaluateBrowsers checks that the navigation of each browser is
+// detected as that browser and passes every check.
+func TestEvaluateBrowsers(t *testing.T) {
+	var h HeaderChecker
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		browser useragent.BrowserKind
+	}{
+		{"chrome", chromeHeaders(), useragent.BrowserChrome},
+		{"chrome on android", androidChromeHeaders(), useragent.BrowserChromeAndroid},
+		{"firefox", firefoxHeaders(), useragent.BrowserFirefox},
+		{"firefox esr below the version floor", withHeaders(firefoxHeaders(), map[string]string{
+			"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0",
+			"Accept-Language": "de-DE,de;q=0.8,en-US;q=0.5,en;q=0.3",
+		}), useragent.BrowserFirefox},
+		{"firefox on android", withHeaders(firefoxHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (Android 10; Mobile; rv:146.0) Gecko/146.0 Firefox/146.0",
+		}), useragent.BrowserFirefoxAndroid},
+		{"tor browser allowed by default", torHeaders(), useragent.BrowserTor},
+		{"safari on macos", safariHeaders(), useragent.BrowserSafari},
+		{"safari on iphone", withHeaders(safariHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 18_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
+		}), useragent.BrowserSafari},
+		{"safari 16 without sec-fetch", withHeaders(safariHeaders(), map[string]string{
+			"User-Agent":     "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.3 Safari/605.1.15",
+			"Sec-Fetch-Dest": "",
+			"Sec-Fetch-Mode": "",
+			"Sec-Fetch-Site": "",
+		}), useragent.BrowserSafari},
+		{"chrome on iphone", iosChromeHeaders(), useragent.BrowserChromeIOS},
+		{"firefox on ipad", withHeaders(iosChromeHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPad; CPU OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/146.0 Mobile/15E148 Safari/605.1.15",
+		}), useragent.BrowserFirefoxIOS},
+		{"edge on iphone", withHeaders(iosChromeHeaders(), map[string]string{
+			"User-Agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 18_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) EdgiOS/143.0.3650.130 Version/18.0 Mobile/15E148 Safari/604.1",
+		}), useragent.BrowserEdgeIOS},
+		{"edge", edgeHeaders(), useragent.BrowserEdge},
+		{"edge webview2", webView2Headers(), useragent.BrowserEdgeWebView2},
+		{"edge on android", edgeAndroidHeaders(), useragent.BrowserEdgeAndroid},
+		{"opera", operaHeaders(), useragent.BrowserOpera},
+		{"samsung internet", samsungHeaders(), useragent.BrowserSamsung},
+		{"yandex browser", yandexHeaders(), useragent.BrowserYandex},
+		{"vivaldi with its token", withHeaders(chromeHeaders(), map[string]string{
+			"User-Agent": chromeHeaders()["User-Agent"] + " Vivaldi/7.6.3797.52",
+		}), useragent.BrowserVivaldi},
+		{"brave", braveHeaders(), useragent.BrowserBrave},
+		{"brave with farbled device memory", withHeaders(braveHeaders(), map[string]string{"Sec-Ch-Device-Memory": "0.5"}), useragent.BrowserBrave},
+		{"brave without the full version list", withHeaders(braveHeaders(), map[string]string{"Sec-Ch-Ua-Full-Version-List": ""}), useragent.BrowserBrave},
+		{"brave accept variant", withHeaders(braveHeaders(), map[string]string{
+			"Accept": "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		}), useragent.BrowserBrave},
+		{"android webview", webViewHeaders(), useragent.BrowserAndroidWebView},
+		{"facebook", facebookHeaders(), useragent.BrowserInApp},
+		{"instagram", withHeaders(facebookHeaders(), map[string]string{
+			"User-Agent":       "Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A.240105.004; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/144.0.7559.59 Mobile Safari/537.36 Instagram 400.0.0.41.88 Android (34/14; 420dpi; 1080x2400; Google; Pixel 7; panther; tensor; en_US; 789012345)",
+			"X-Requested-With": "com.instagram.android",
+		}), useragent.BrowserInApp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := h.Evaluate(newRequest("/", tt.headers))
+			if v.Browser != tt.browser {
+				t.Errorf("Browser = %q, want %q", v.Browser, tt.browser)
+			}
+			if len(v.Findings) != 0 {
+				t.Errorf("Findings = %+v, want none", v.Findings)
+			}
+		})
+	}
+}